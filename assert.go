@@ -1,13 +1,14 @@
 package iapetus
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 
-	"errors"
-
-	jd "github.com/josephburnett/jd/lib"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 // Aggregate assertion errors
@@ -23,12 +24,31 @@ func (ae AssertionErrors) Error() string {
 	return strings.Join(msgs, "; ")
 }
 
-// RunAssertions runs all assertions and aggregates errors
+// Unwrap returns the individual failures so errors.As can find an *AssertionError.
+func (ae AssertionErrors) Unwrap() []error {
+	return ae
+}
+
+// RunAssertions runs all assertions and aggregates errors.
+// Every failure is returned as an *AssertionFailure recording which assertion
+// reported it. Assertions that report several failures (e.g. one per JSON path)
+// are flattened. Warnings (see Warn) are stored in task.Actual.Warnings and not returned.
 func RunAssertions(task *Task) error {
+	task.Actual.Warnings = nil
 	var errs AssertionErrors
-	for _, assert := range task.Asserts {
-		if err := assert(task); err != nil {
-			errs = append(errs, err)
+	for i, assert := range task.Asserts {
+		failures, _ := AllOf(assert)(task).(AssertionErrors)
+		for _, f := range failures {
+			ae, ok := f.(*AssertionError)
+			if ok && ae.IsWarning() {
+				task.Actual.Warnings = append(task.Actual.Warnings, ae)
+				continue
+			}
+			failure := &AssertionFailure{Task: task.Name, Index: i, Err: f}
+			if ok {
+				failure.Kind = ae.Kind
+			}
+			errs = append(errs, failure)
 		}
 	}
 	if len(errs) > 0 {
@@ -49,7 +69,12 @@ func normalizeOutput(s string) string {
 func AssertExitCode(expected int) func(*Task) error {
 	return func(i *Task) error {
 		if i.Actual.ExitCode != expected {
-			return fmt.Errorf("exit code mismatch: expected %d, got %d", expected, i.Actual.ExitCode)
+			return &AssertionError{
+				Kind:     KindExitCode,
+				Message:  "exit code mismatch",
+				Expected: fmt.Sprint(expected),
+				Actual:   fmt.Sprint(i.Actual.ExitCode),
+			}
 		}
 		return nil
 	}
@@ -59,7 +84,12 @@ func AssertExitCode(expected int) func(*Task) error {
 func AssertOutputContains(substr string) func(*Task) error {
 	return func(i *Task) error {
 		if !strings.Contains(i.Actual.Output, substr) {
-			return fmt.Errorf("output does not contain expected substring: %q", substr)
+			return &AssertionError{
+				Kind:     KindOutputContains,
+				Message:  "output does not contain expected substring",
+				Expected: fmt.Sprintf("%q", substr),
+				Actual:   quoteForDisplay(i.Actual.Output),
+			}
 		}
 		return nil
 	}
@@ -71,36 +101,77 @@ func AssertOutputEquals(expected string) func(*Task) error {
 		actual := normalizeOutput(i.Actual.Output)
 		exp := normalizeOutput(expected)
 		if actual != exp {
-			return fmt.Errorf("output mismatch: expected %q, got %q", exp, actual)
+			return textMismatch(KindOutputEquals, "output mismatch", exp, actual)
 		}
 		return nil
 	}
 }
 
-// AssertOutputJsonEquals returns an assertion that checks if output JSON matches expected JSON
+// AssertOutputJsonEquals returns an assertion that checks if output JSON matches expected JSON.
+// skipJsonNodes are skip paths as described in JSONCompareOptions.SkipPaths.
 func AssertOutputJsonEquals(expected string, skipJsonNodes ...string) func(*Task) error {
+	return AssertOutputJsonEqualsWithOptions(expected, JSONCompareOptions{SkipPaths: skipJsonNodes})
+}
+
+// AssertOutputJsonEqualsWithOptions returns an assertion that checks if output JSON matches expected JSON,
+// with wildcard skip paths, array-order-insensitive comparison and numeric tolerance
+func AssertOutputJsonEqualsWithOptions(expected string, opts JSONCompareOptions) func(*Task) error {
+	return func(i *Task) error {
+		actual, exp, err := parseJSONOutputs(normalizeOutput(i.Actual.Output), expected)
+		if err != nil {
+			return err
+		}
+		mismatches, err := compareJSON(exp, actual, opts)
+		if err != nil {
+			return err
+		}
+		return mismatchError(KindJSONEquals, mismatches)
+	}
+}
+
+// AssertOutputYamlEquals returns an assertion that checks if YAML output (single or multi-document)
+// matches expected YAML, ignoring skip paths as described in JSONCompareOptions.SkipPaths
+func AssertOutputYamlEquals(expected string, skipNodes ...string) func(*Task) error {
+	return AssertOutputStructuredEquals(FormatYAML, expected, JSONCompareOptions{SkipPaths: skipNodes})
+}
+
+// AssertOutputStructuredEquals returns an assertion that parses both the output and expected
+// in the given format (json, yaml, toml or csv) and compares them like AssertOutputJsonEqualsWithOptions
+func AssertOutputStructuredEquals(format, expected string, opts JSONCompareOptions) func(*Task) error {
 	return func(i *Task) error {
-		expectation, err := jd.ReadJsonString(expected)
+		exp, err := ParseStructuredOutput(format, expected)
 		if err != nil {
 			return fmt.Errorf("failed to read expectation: %w", err)
 		}
-		parsedOutput, err := jd.ReadJsonString(normalizeOutput(i.Actual.Output))
+		actual, err := ParseStructuredOutput(format, normalizeOutput(i.Actual.Output))
 		if err != nil {
 			return fmt.Errorf("failed to parse output: %w", err)
 		}
-		diff := expectation.Diff(parsedOutput)
-		var errs []string
-		for _, d := range diff {
-			if shouldSkipPath(d.Path, skipJsonNodes) {
-				continue
-			}
-			errs = append(errs, fmt.Sprintf("mismatch at path %v: expected %v, got %v", d.Path, d.NewValues, d.OldValues))
-		}
-		if len(errs) > 0 {
-			return errors.New(strings.Join(errs, "; "))
+		mismatches, err := compareJSON(exp, actual, opts)
+		if err != nil {
+			return err
 		}
+		return mismatchError("output_"+strings.ToLower(format)+"_equals", mismatches)
+	}
+}
+
+// mismatchError reports structured comparison mismatches as one AssertionError per path,
+// or nil if there are none
+func mismatchError(kind string, mismatches []jsonMismatch) error {
+	if len(mismatches) == 0 {
 		return nil
 	}
+	errs := make(AssertionErrors, 0, len(mismatches))
+	for _, m := range mismatches {
+		errs = append(errs, &AssertionError{
+			Kind:     kind,
+			Message:  "mismatch at path " + m.Path,
+			Expected: m.Expected,
+			Actual:   m.Actual,
+			Path:     m.Path,
+		})
+	}
+	return errs
 }
 
 // AssertOutputMatchesRegexp returns an assertion that checks if output matches a regexp
@@ -112,23 +183,239 @@ func AssertOutputMatchesRegexp(pattern string) func(*Task) error {
 			return fmt.Errorf("invalid regexp pattern %q: %v", pattern, err)
 		}
 		if !matched {
-			return fmt.Errorf("output does not match pattern: %q", pattern)
+			return &AssertionError{
+				Kind:     KindOutputRegexp,
+				Message:  fmt.Sprintf("output does not match pattern: %q", pattern),
+				Expected: fmt.Sprintf("match for %q", pattern),
+				Actual:   quoteForDisplay(actual),
+			}
 		}
 		return nil
 	}
 }
 
-// Helper for robust JSON path skipping
-func shouldSkipPath(path []jd.JsonNode, skipPaths []string) bool {
-	var pathStrs []string
-	for _, node := range path {
-		pathStrs = append(pathStrs, node.Json())
+// AssertStatusCode returns an assertion that checks the HTTP status code of the response
+func AssertStatusCode(expected int) func(*Task) error {
+	return func(i *Task) error {
+		if i.Actual.StatusCode != expected {
+			return &AssertionError{
+				Kind:     KindStatusCode,
+				Message:  "status code mismatch",
+				Expected: fmt.Sprint(expected),
+				Actual:   fmt.Sprint(i.Actual.StatusCode),
+			}
+		}
+		return nil
 	}
-	joined := strings.Join(pathStrs, ".")
-	for _, skip := range skipPaths {
-		if joined == skip {
-			return true
+}
+
+// AssertHeaderEquals returns an assertion that checks an HTTP response header value
+func AssertHeaderEquals(name, expected string) func(*Task) error {
+	return func(i *Task) error {
+		values := http.Header(i.Actual.Headers).Values(name)
+		if len(values) == 0 {
+			return &AssertionError{Kind: KindHeader, Message: fmt.Sprintf("header %q not present in response", name)}
 		}
+		for _, v := range values {
+			if v == expected {
+				return nil
+			}
+		}
+		return &AssertionError{
+			Kind:     KindHeader,
+			Message:  fmt.Sprintf("header %q mismatch", name),
+			Expected: fmt.Sprintf("%q", expected),
+			Actual:   fmt.Sprintf("%q", strings.Join(values, ", ")),
+		}
+	}
+}
+
+// AssertJSONPathEquals returns an assertion that checks the value at a JSON path in the output
+func AssertJSONPathEquals(path string, expected interface{}) func(*Task) error {
+	return AssertOutputPath(FormatJSON, path, PathEquals(expected))
+}
+
+// AssertJSONPathContains returns an assertion that checks the value at a JSON path contains expected.
+// Strings match by substring, arrays by element and objects by key.
+func AssertJSONPathContains(path string, expected interface{}) func(*Task) error {
+	return AssertOutputPath(FormatJSON, path, PathContains(expected))
+}
+
+// AssertJSONPathGreaterThan returns an assertion that checks the number at a JSON path is greater than min
+func AssertJSONPathGreaterThan(path string, min float64) func(*Task) error {
+	return AssertOutputPath(FormatJSON, path, PathGreaterThan(min))
+}
+
+// AssertJSONPathLength returns an assertion that checks the length of the array, object or string at a JSON path
+func AssertJSONPathLength(path string, expected int) func(*Task) error {
+	return AssertOutputPath(FormatJSON, path, PathLength(expected))
+}
+
+// AssertJSONPathMatches returns an assertion that checks the value at a JSON path matches a regexp.
+// Non-string scalars are matched against their JSON encoding.
+func AssertJSONPathMatches(path string, pattern string) func(*Task) error {
+	return AssertOutputPath(FormatJSON, path, PathMatches(pattern))
+}
+
+// AssertYAMLPathEquals returns an assertion that checks the value at a path in YAML output.
+// Multi-document output is a list of documents, so "[1].kind" selects the second document.
+func AssertYAMLPathEquals(path string, expected interface{}) func(*Task) error {
+	return AssertOutputPath(FormatYAML, path, PathEquals(expected))
+}
+
+// PathCheck validates the value found at a path by AssertOutputPath.
+type PathCheck func(path string, actual interface{}) error
+
+// AssertOutputPath returns an assertion that parses the output in the given format
+// (see ParseStructuredOutput), looks up path and applies check to the value found.
+// A path that does not resolve in the output fails the check like a mismatch.
+func AssertOutputPath(format, path string, check PathCheck) func(*Task) error {
+	return func(i *Task) error {
+		doc, err := ParseStructuredOutput(format, normalizeOutput(i.Actual.Output))
+		if err != nil {
+			return fmt.Errorf("failed to parse output: %w", err)
+		}
+		actual, err := lookupJSONPath(doc, path)
+		var missing *pathNotFoundError
+		if errors.As(err, &missing) {
+			return pathMismatch(path, missing.Error(), "", "")
+		}
+		if err != nil {
+			return err
+		}
+		return check(path, actual)
+	}
+}
+
+// pathMismatch builds the AssertionError reported by the path checks
+func pathMismatch(path, message, expected, actual string) error {
+	return &AssertionError{Kind: KindJSONPath, Message: message, Expected: expected, Actual: actual, Path: path}
+}
+
+// jsonDisplay renders a value as JSON for assertion messages
+func jsonDisplay(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// PathEquals checks that the value equals expected using JSON semantics
+func PathEquals(expected interface{}) PathCheck {
+	return func(path string, actual interface{}) error {
+		if !jsonValuesEqual(actual, expected) {
+			return pathMismatch(path, "path "+path+" mismatch", jsonDisplay(expected), jsonDisplay(actual))
+		}
+		return nil
+	}
+}
+
+// PathContains checks that a string contains a substring, an array an element, or an object a key
+func PathContains(expected interface{}) PathCheck {
+	return func(path string, actual interface{}) error {
+		switch v := actual.(type) {
+		case string:
+			s, ok := expected.(string)
+			if ok && strings.Contains(v, s) {
+				return nil
+			}
+		case []interface{}:
+			for _, item := range v {
+				if jsonValuesEqual(item, expected) {
+					return nil
+				}
+			}
+		case map[string]interface{}:
+			if s, ok := expected.(string); ok {
+				if _, found := v[s]; found {
+					return nil
+				}
+			}
+		default:
+			return fmt.Errorf("path %s: contains requires a string, array or object, got %s", path, jsonTypeName(actual))
+		}
+		return pathMismatch(path, "path "+path+" does not contain expected value", jsonDisplay(expected), jsonDisplay(actual))
+	}
+}
+
+// PathGreaterThan checks that the value is a number greater than min
+func PathGreaterThan(min float64) PathCheck {
+	return func(path string, actual interface{}) error {
+		n, ok := actual.(float64)
+		if !ok {
+			return fmt.Errorf("path %s: expected a number, got %s", path, jsonTypeName(actual))
+		}
+		if n <= min {
+			return pathMismatch(path, "path "+path+" is not greater than expected", fmt.Sprintf("> %v", min), jsonDisplay(n))
+		}
+		return nil
+	}
+}
+
+// PathLength checks the length of an array, object or string
+func PathLength(expected int) PathCheck {
+	return func(path string, actual interface{}) error {
+		var n int
+		switch v := actual.(type) {
+		case []interface{}:
+			n = len(v)
+		case map[string]interface{}:
+			n = len(v)
+		case string:
+			n = len(v)
+		default:
+			return fmt.Errorf("path %s: length requires an array, object or string, got %s", path, jsonTypeName(actual))
+		}
+		if n != expected {
+			return pathMismatch(path, "path "+path+" length mismatch", fmt.Sprint(expected), fmt.Sprint(n))
+		}
+		return nil
+	}
+}
+
+// PathMatches checks that the value (or the JSON encoding of a non-string) matches a regexp
+func PathMatches(pattern string) PathCheck {
+	re, compileErr := regexp.Compile(pattern)
+	return func(path string, actual interface{}) error {
+		if compileErr != nil {
+			return fmt.Errorf("invalid regexp pattern %q: %v", pattern, compileErr)
+		}
+		s, ok := actual.(string)
+		if !ok {
+			data, _ := json.Marshal(actual)
+			s = string(data)
+		}
+		if !re.MatchString(s) {
+			return pathMismatch(path, fmt.Sprintf("path %s does not match pattern %q", path, pattern), fmt.Sprintf("match for %q", pattern), fmt.Sprintf("%q", s))
+		}
+		return nil
+	}
+}
+
+// AssertOutputJSONSchema returns an assertion that validates the output against a JSON Schema document
+func AssertOutputJSONSchema(schema string) func(*Task) error {
+	compiled, compileErr := compileJSONSchema(schema)
+	return func(i *Task) error {
+		if compileErr != nil {
+			return compileErr
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(normalizeOutput(i.Actual.Output)), &doc); err != nil {
+			return fmt.Errorf("failed to parse output as JSON: %w", err)
+		}
+		if err := compiled.Validate(doc); err != nil {
+			return &AssertionError{Kind: KindJSONSchema, Message: fmt.Sprintf("output does not match JSON schema: %v", err)}
+		}
+		return nil
+	}
+}
+
+// compileJSONSchema compiles an inline JSON Schema document
+func compileJSONSchema(schema string) (*jsonschema.Schema, error) {
+	compiled, err := jsonschema.CompileString("schema.json", schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
 	}
-	return false
+	return compiled, nil
 }
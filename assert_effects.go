@@ -0,0 +1,203 @@
+package iapetus
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvFileVar names the environment variable holding the path of a file where a
+// bash task can export side effects as KEY=VALUE lines, e.g.
+//
+//	echo "VERSION=1.2.3" >> "$IAPETUS_ENV"
+//
+// The exported variables are available in Task.Actual.Env after the task runs.
+const EnvFileVar = "IAPETUS_ENV"
+
+// Assertion kinds reported by the duration, file and environment assertions.
+const (
+	KindDuration = "duration"
+	KindFile     = "file"
+	KindEnv      = "env"
+)
+
+// readEnvFile parses KEY=VALUE lines written to an env file. Blank lines and
+// lines starting with '#' are ignored; later assignments win.
+func readEnvFile(path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var env map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[strings.TrimSpace(strings.TrimPrefix(k, "export "))] = v
+	}
+	return env
+}
+
+// taskFilePath resolves a relative path against the task's WorkingDir.
+func taskFilePath(t *Task, path string) string {
+	if filepath.IsAbs(path) || t.WorkingDir == "" {
+		return path
+	}
+	return filepath.Join(t.WorkingDir, path)
+}
+
+// AssertDurationUnder returns an assertion that checks the task completed in less than max
+func AssertDurationUnder(max time.Duration) func(*Task) error {
+	return func(i *Task) error {
+		if i.Actual.Duration >= max {
+			return &AssertionError{
+				Kind:     KindDuration,
+				Message:  "task took too long",
+				Expected: "< " + max.String(),
+				Actual:   i.Actual.Duration.String(),
+			}
+		}
+		return nil
+	}
+}
+
+// AssertFileExists returns an assertion that checks a file exists (relative to WorkingDir)
+func AssertFileExists(path string) func(*Task) error {
+	return func(i *Task) error {
+		p := taskFilePath(i, path)
+		if _, err := os.Stat(p); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to check file %s: %w", p, err)
+			}
+			return &AssertionError{Kind: KindFile, Message: fmt.Sprintf("file %s does not exist", p), Path: p}
+		}
+		return nil
+	}
+}
+
+// readCheckedFile reads a file for an assertion. A missing file fails the check;
+// any other error (e.g. a permission error) is returned as is, as in AssertFileExists.
+func readCheckedFile(p string) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &AssertionError{Kind: KindFile, Message: fmt.Sprintf("file %s does not exist", p), Path: p}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", p, err)
+	}
+	return data, nil
+}
+
+// AssertFileContains returns an assertion that checks a file contains a substring
+func AssertFileContains(path, substr string) func(*Task) error {
+	return func(i *Task) error {
+		p := taskFilePath(i, path)
+		data, err := readCheckedFile(p)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(data), substr) {
+			return &AssertionError{
+				Kind:     KindFile,
+				Message:  fmt.Sprintf("file %s does not contain expected substring", p),
+				Expected: fmt.Sprintf("%q", substr),
+				Actual:   quoteForDisplay(string(data)),
+			}
+		}
+		return nil
+	}
+}
+
+// AssertFileChecksum returns an assertion that checks the SHA-256 of a file.
+// expected is a hex digest, optionally prefixed with "sha256:".
+func AssertFileChecksum(path, expected string) func(*Task) error {
+	want := strings.ToLower(strings.TrimPrefix(expected, "sha256:"))
+	return func(i *Task) error {
+		p := taskFilePath(i, path)
+		data, err := readCheckedFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			return &AssertionError{
+				Kind:     KindFile,
+				Message:  fmt.Sprintf("file %s checksum mismatch", p),
+				Expected: "sha256:" + want,
+				Actual:   "sha256:" + got,
+			}
+		}
+		return nil
+	}
+}
+
+// AssertFileJSONEquals returns an assertion that checks a JSON file matches expected JSON,
+// with the same options and per-path failures as AssertOutputJsonEqualsWithOptions
+func AssertFileJSONEquals(path, expected string, opts JSONCompareOptions) func(*Task) error {
+	return func(i *Task) error {
+		p := taskFilePath(i, path)
+		data, err := readCheckedFile(p)
+		if err != nil {
+			return err
+		}
+		var actual, exp interface{}
+		if err := json.Unmarshal(data, &actual); err != nil {
+			return fmt.Errorf("failed to parse file %s as JSON: %w", p, err)
+		}
+		if err := json.Unmarshal([]byte(expected), &exp); err != nil {
+			return fmt.Errorf("failed to parse expected JSON: %w", err)
+		}
+		mismatches, err := compareJSON(exp, actual, opts)
+		if err != nil {
+			return err
+		}
+		return mismatchError(KindFile, mismatches)
+	}
+}
+
+// AssertEnvEquals returns an assertion that checks a variable exported via $IAPETUS_ENV
+func AssertEnvEquals(name, expected string) func(*Task) error {
+	return func(i *Task) error {
+		v, ok := i.Actual.Env[name]
+		if !ok {
+			return &AssertionError{Kind: KindEnv, Message: fmt.Sprintf("variable %s was not exported", name)}
+		}
+		if v != expected {
+			return &AssertionError{
+				Kind:     KindEnv,
+				Message:  fmt.Sprintf("variable %s mismatch", name),
+				Expected: fmt.Sprintf("%q", expected),
+				Actual:   fmt.Sprintf("%q", v),
+			}
+		}
+		return nil
+	}
+}
+
+// AssertEnvSet returns an assertion that checks a variable was exported via $IAPETUS_ENV
+func AssertEnvSet(name string) func(*Task) error {
+	return func(i *Task) error {
+		if _, ok := i.Actual.Env[name]; !ok {
+			return &AssertionError{Kind: KindEnv, Message: fmt.Sprintf("variable %s was not exported", name)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package iapetus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssertDurationUnder(t *testing.T) {
+	task := &Task{Actual: Output{Duration: 150 * time.Millisecond}}
+	if err := AssertDurationUnder(time.Second)(task); err != nil {
+		t.Errorf("expected pass, got %v", err)
+	}
+	err := AssertDurationUnder(100 * time.Millisecond)(task)
+	if err == nil || !strings.Contains(err.Error(), "expected < 100ms, got 150ms") {
+		t.Errorf("expected duration failure, got %v", err)
+	}
+}
+
+func TestFileAssertions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.json"), []byte(`{"name":"app","id":7}`), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	task := &Task{WorkingDir: dir}
+	const wrongSum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name    string
+		assert  func(*Task) error
+		wantErr bool
+	}{
+		{"exists", AssertFileExists("out.json"), false},
+		{"absolute path", AssertFileExists(filepath.Join(dir, "out.json")), false},
+		{"missing", AssertFileExists("missing.json"), true},
+		{"not missing", Not(AssertFileExists("missing.json")), false},
+		{"not existing", Not(AssertFileExists("out.json")), true},
+		{"not unreadable", Not(AssertFileExists("out.json/child")), true},
+		{"not contains missing", Not(AssertFileContains("missing.json", "secret")), false},
+		{"not contains unreadable", Not(AssertFileContains("out.json/child", "secret")), true},
+		{"not contains directory", Not(AssertFileContains(".", "secret")), true},
+		{"not checksum unreadable", Not(AssertFileChecksum(".", wrongSum)), true},
+		{"not json unreadable", Not(AssertFileJSONEquals(".", `{}`, JSONCompareOptions{})), true},
+		{"contains", AssertFileContains("out.json", `"app"`), false},
+		{"does not contain", AssertFileContains("out.json", "web"), true},
+		{"wrong checksum", AssertFileChecksum("out.json", wrongSum), true},
+		{"json equals", AssertFileJSONEquals("out.json", `{"id":7,"name":"app"}`, JSONCompareOptions{}), false},
+		{"json skip", AssertFileJSONEquals("out.json", `{"name":"app"}`, JSONCompareOptions{SkipPaths: []string{"id"}}), false},
+		{"json mismatch", AssertFileJSONEquals("out.json", `{"name":"web","id":7}`, JSONCompareOptions{}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.assert(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	const helloSum = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if err := AssertFileChecksum("hello.txt", "sha256:"+helloSum)(task); err != nil {
+		t.Errorf("expected checksum to match, got %v", err)
+	}
+}
+
+func TestEnvAssertions(t *testing.T) {
+	task := &Task{Actual: Output{Env: map[string]string{"VERSION": "1.2.3"}}}
+	if err := AssertEnvEquals("VERSION", "1.2.3")(task); err != nil {
+		t.Errorf("expected pass, got %v", err)
+	}
+	if err := AssertEnvEquals("VERSION", "2.0.0")(task); err == nil {
+		t.Errorf("expected mismatch")
+	}
+	if err := AssertEnvSet("BUILD_ID")(task); err == nil || !strings.Contains(err.Error(), "was not exported") {
+		t.Errorf("expected missing variable error, got %v", err)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env")
+	content := "# comment\nA=1\n\nexport B=two=2\nA=3\nnot-an-assignment\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	env := readEnvFile(path)
+	if len(env) != 2 || env["A"] != "3" || env["B"] != "two=2" {
+		t.Errorf("unexpected env %v", env)
+	}
+	if readEnvFile(filepath.Join(t.TempDir(), "missing")) != nil {
+		t.Errorf("expected nil for missing file")
+	}
+}
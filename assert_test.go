@@ -1,6 +1,7 @@
 package iapetus
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -202,3 +203,132 @@ func TestAssertOutputMatchesRegexp_NewAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestAssertJSONPathEquals(t *testing.T) {
+	doc := `{"items":[{"name":"a","count":2}],"labels":{"app.kubernetes.io/name":"web"},"ok":true}`
+	tests := []struct {
+		name     string
+		path     string
+		expected interface{}
+		wantErr  bool
+	}{
+		{"Key", "ok", true, false},
+		{"Index", "$.items[0].name", "a", false},
+		{"NegativeIndex", "items[-1].count", 2, false},
+		{"QuotedKey", `labels["app.kubernetes.io/name"]`, "web", false},
+		{"Object", "items[0]", map[string]interface{}{"name": "a", "count": 2}, false},
+		{"Mismatch", "items[0].name", "b", true},
+		{"MissingKey", "items[0].missing", "a", true},
+		{"OutOfRange", "items[3]", "a", true},
+		{"BadIndex", "items[x]", "a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Actual: Output{Output: doc}}
+			err := AssertJSONPathEquals(tt.path, tt.expected)(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssertOutputPath_NotFound(t *testing.T) {
+	task := &Task{Actual: Output{Output: `{"items":[{"name":"a"}],"version":"v1"}`}}
+	for _, path := range []string{"items[0].missing", "items[3]", "version.major", "items.name"} {
+		err := AssertJSONPathEquals(path, "a")(task)
+		var ae *AssertionError
+		if !errors.As(err, &ae) || ae.Kind != KindJSONPath || ae.Path != path {
+			t.Errorf("%s: expected a json_path failure, got %v", path, err)
+		}
+		if err := Not(AssertJSONPathEquals(path, "a"))(task); err != nil {
+			t.Errorf("%s: expected Not to pass for a missing path, got %v", path, err)
+		}
+	}
+	if err := AssertJSONPathEquals("items[x]", "a")(task); err == nil || errors.As(err, new(*AssertionError)) {
+		t.Errorf("expected a plain error for a malformed path, got %v", err)
+	}
+	task.Actual.Output = "not json"
+	if err := Not(AssertJSONPathEquals("items", "a"))(task); err == nil {
+		t.Errorf("expected Not to fail for unparsable output")
+	}
+}
+
+func TestAssertStatusCodeAndHeader(t *testing.T) {
+	task := &Task{Actual: Output{StatusCode: 200, Headers: map[string][]string{"Content-Type": {"text/plain"}}}}
+	if err := AssertStatusCode(200)(task); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := AssertStatusCode(500)(task); err == nil {
+		t.Errorf("expected status code mismatch")
+	}
+	if err := AssertHeaderEquals("content-type", "text/plain")(task); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := AssertHeaderEquals("Content-Type", "application/json")(task); err == nil {
+		t.Errorf("expected header mismatch")
+	}
+	if err := AssertHeaderEquals("X-Missing", "x")(task); err == nil {
+		t.Errorf("expected missing header error")
+	}
+}
+
+func TestAssertJSONPathOperators(t *testing.T) {
+	doc := `{"items":[{"name":"a","phase":"Running"},{"name":"b","phase":"Running"}],"count":3,"version":"v1.2.0","labels":{"tier":"web"}}`
+	tests := []struct {
+		name    string
+		assert  func(*Task) error
+		wantErr bool
+	}{
+		{"ContainsSubstring", AssertJSONPathContains("version", "1.2"), false},
+		{"ContainsElement", AssertJSONPathContains("items[*].name", "b"), false},
+		{"ContainsKey", AssertJSONPathContains("labels", "tier"), false},
+		{"ContainsMissing", AssertJSONPathContains("items[*].name", "c"), true},
+		{"ContainsNumber", AssertJSONPathContains("count", 3), true},
+		{"GreaterThan", AssertJSONPathGreaterThan("count", 2), false},
+		{"NotGreaterThan", AssertJSONPathGreaterThan("count", 3), true},
+		{"GreaterThanString", AssertJSONPathGreaterThan("version", 1), true},
+		{"LengthArray", AssertJSONPathLength("items", 2), false},
+		{"LengthObject", AssertJSONPathLength("labels", 1), false},
+		{"LengthMismatch", AssertJSONPathLength("items", 3), true},
+		{"WildcardEquals", AssertJSONPathEquals("items[*].phase", []string{"Running", "Running"}), false},
+		{"Matches", AssertJSONPathMatches("version", `^v\d+\.\d+`), false},
+		{"MatchesNumber", AssertJSONPathMatches("count", `^3$`), false},
+		{"NoMatch", AssertJSONPathMatches("version", `^v2`), true},
+		{"InvalidPattern", AssertJSONPathMatches("version", `v[`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Actual: Output{Output: doc}}
+			err := tt.assert(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssertOutputJSONSchema(t *testing.T) {
+	schema := `{"type":"object","required":["name"],"properties":{"name":{"type":"string"},"replicas":{"type":"integer","minimum":1}}}`
+	tests := []struct {
+		name    string
+		actual  string
+		schema  string
+		wantErr bool
+	}{
+		{"Valid", `{"name":"web","replicas":2}`, schema, false},
+		{"MissingRequired", `{"replicas":2}`, schema, true},
+		{"WrongType", `{"name":"web","replicas":0}`, schema, true},
+		{"InvalidJSON", `not json`, schema, true},
+		{"InvalidSchema", `{}`, `{"type":`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Actual: Output{Output: tt.actual}}
+			err := AssertOutputJSONSchema(tt.schema)(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
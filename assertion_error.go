@@ -0,0 +1,186 @@
+package iapetus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Assertion kinds reported in AssertionError.Kind.
+const (
+	KindExitCode       = "exit_code"
+	KindOutputEquals   = "output_equals"
+	KindOutputContains = "output_contains"
+	KindOutputRegexp   = "output_matches_regexp"
+	KindJSONEquals     = "output_json_equals"
+	KindJSONPath       = "json_path"
+	KindJSONSchema     = "output_json_schema"
+	KindGolden         = "output_golden"
+	KindStatusCode     = "status_code"
+	KindHeader         = "header_equals"
+)
+
+// Assertion severities. Failures with SeverityWarning are recorded on the task
+// (Output.Warnings) but do not fail it; see Warn.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// AssertionError is a structured assertion failure.
+//
+// Expected and Actual hold display strings (quoted for text, JSON for JSON values).
+// Path is set for failures inside a structured document, and Diff holds a unified
+// diff for multi-line text comparisons.
+type AssertionError struct {
+	// Kind identifies the assertion, e.g. KindOutputEquals or "output_yaml_equals"
+	Kind string
+	// Message summarizes the failure
+	Message  string
+	Expected string
+	Actual   string
+	Path     string
+	Diff     string
+	// Severity is SeverityWarning for non-fatal findings; empty means SeverityError
+	Severity string
+}
+
+// Error implements the error interface for AssertionError.
+func (e *AssertionError) Error() string {
+	msg := e.Message
+	if e.IsWarning() {
+		msg = "warning: " + msg
+	}
+	if e.Diff != "" {
+		return msg + ":\n" + strings.TrimSuffix(e.Diff, "\n")
+	}
+	if e.Expected != "" || e.Actual != "" {
+		return fmt.Sprintf("%s: expected %s, got %s", msg, e.Expected, e.Actual)
+	}
+	return msg
+}
+
+// IsWarning reports whether the failure is a non-fatal warning.
+func (e *AssertionError) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// maxDisplayLen bounds the length of output quoted in single-line assertion messages.
+const maxDisplayLen = 200
+
+// quoteForDisplay quotes s for an assertion message, truncating long values.
+func quoteForDisplay(s string) string {
+	if len(s) > maxDisplayLen {
+		return fmt.Sprintf("%q...", s[:maxDisplayLen])
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// textMismatch builds an AssertionError comparing two strings, using a unified diff
+// when either side spans several lines.
+func textMismatch(kind, message, expected, actual string) *AssertionError {
+	if strings.Contains(expected, "\n") || strings.Contains(actual, "\n") {
+		return &AssertionError{
+			Kind:     kind,
+			Message:  message,
+			Expected: expected,
+			Actual:   actual,
+			Diff:     UnifiedDiff("expected", "actual", expected+"\n", actual+"\n"),
+		}
+	}
+	return &AssertionError{Kind: kind, Message: message, Expected: fmt.Sprintf("%q", expected), Actual: fmt.Sprintf("%q", actual)}
+}
+
+// AssertionFailures returns every *AssertionError found in err's chain, flattening
+// AssertionErrors and wrapped errors (e.g. WorkflowError). Of a
+// RetriesExhaustedError only the last attempt is inspected.
+func AssertionFailures(err error) []*AssertionError {
+	var out []*AssertionError
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+		if ae, ok := err.(*AssertionError); ok {
+			out = append(out, ae)
+			return
+		}
+		if re, ok := err.(*RetriesExhaustedError); ok {
+			// Earlier attempts failed for reasons the last one superseded.
+			walk(re.Last())
+			return
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				walk(e)
+			}
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap())
+		}
+	}
+	walk(err)
+	return out
+}
+
+// ANSI color codes used by RenderAssertionErrors.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// RenderAssertionErrors renders the assertion failures in err for humans: unified diffs for
+// text and one expected/actual pair per path for structured documents. With color, removed
+// lines are red and added lines green. Returns "" if err contains no AssertionError.
+func RenderAssertionErrors(err error, color bool) string {
+	failures := AssertionFailures(err)
+	if len(failures) == 0 {
+		return ""
+	}
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	var sb strings.Builder
+	for _, f := range failures {
+		kind := f.Kind
+		if kind == "" {
+			kind = "assertion"
+		}
+		if f.IsWarning() {
+			kind += " warning"
+		}
+		switch {
+		case f.Diff != "":
+			fmt.Fprintf(&sb, "%s %s\n", paint(ansiBold, "["+kind+"]"), f.Message)
+			for _, line := range strings.Split(strings.TrimSuffix(f.Diff, "\n"), "\n") {
+				switch {
+				case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+					line = paint(ansiBold, line)
+				case strings.HasPrefix(line, "@@"):
+					line = paint(ansiCyan, line)
+				case strings.HasPrefix(line, "-"):
+					line = paint(ansiRed, line)
+				case strings.HasPrefix(line, "+"):
+					line = paint(ansiGreen, line)
+				}
+				fmt.Fprintf(&sb, "    %s\n", line)
+			}
+		case f.Path != "":
+			fmt.Fprintf(&sb, "%s %s\n", paint(ansiBold, "["+kind+"]"), paint(ansiCyan, f.Path))
+			fmt.Fprintf(&sb, "    %s\n", paint(ansiRed, "- "+f.Expected))
+			fmt.Fprintf(&sb, "    %s\n", paint(ansiGreen, "+ "+f.Actual))
+		case f.Expected != "" || f.Actual != "":
+			fmt.Fprintf(&sb, "%s %s\n", paint(ansiBold, "["+kind+"]"), f.Message)
+			fmt.Fprintf(&sb, "    expected: %s\n", paint(ansiRed, f.Expected))
+			fmt.Fprintf(&sb, "    actual:   %s\n", paint(ansiGreen, f.Actual))
+		default:
+			fmt.Fprintf(&sb, "%s %s\n", paint(ansiBold, "["+kind+"]"), f.Message)
+		}
+	}
+	return sb.String()
+}
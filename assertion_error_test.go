@@ -0,0 +1,92 @@
+package iapetus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssertionError_Structured(t *testing.T) {
+	task := &Task{
+		Actual: Output{ExitCode: 2, Output: "line1\nline2\nline3"},
+		Asserts: []func(*Task) error{
+			AssertExitCode(0),
+			AssertOutputEquals("line1\nchanged\nline3"),
+			AssertOutputContains("missing"),
+		},
+	}
+	err := RunAssertions(task)
+	failures := AssertionFailures(err)
+	if len(failures) != 3 {
+		t.Fatalf("expected 3 assertion failures, got %d: %v", len(failures), err)
+	}
+	if f := failures[0]; f.Kind != KindExitCode || f.Expected != "0" || f.Actual != "2" {
+		t.Errorf("unexpected exit code failure: %+v", f)
+	}
+	if f := failures[1]; f.Kind != KindOutputEquals || !strings.Contains(f.Diff, "-changed\n+line2\n") {
+		t.Errorf("expected unified diff for multi-line output, got %+v", f)
+	}
+	if failures[2].Kind != KindOutputContains {
+		t.Errorf("unexpected kind %q", failures[2].Kind)
+	}
+
+	var ae *AssertionError
+	wrapped := &WorkflowError{StepName: "s", WorkflowName: "w", Err: err}
+	if !errors.As(wrapped, &ae) || ae.Kind != KindExitCode {
+		t.Errorf("expected errors.As to find the first AssertionError through WorkflowError, got %v", ae)
+	}
+	if len(AssertionFailures(wrapped)) != 3 {
+		t.Errorf("expected failures to be found through WorkflowError")
+	}
+}
+
+func TestAssertionError_SingleLineMessage(t *testing.T) {
+	err := AssertOutputEquals("foo")(&Task{Actual: Output{Output: "bar"}})
+	if err == nil || err.Error() != `output mismatch: expected "foo", got "bar"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAssertionError_JSONPathByPath(t *testing.T) {
+	task := &Task{
+		Actual:  Output{Output: `{"a": 1, "b": {"c": "x"}, "d": true}`},
+		Asserts: []func(*Task) error{AssertOutputJsonEquals(`{"a": 2, "b": {"c": "y"}, "d": true}`)},
+	}
+	failures := AssertionFailures(RunAssertions(task))
+	if len(failures) != 2 {
+		t.Fatalf("expected one failure per path, got %d", len(failures))
+	}
+	paths := map[string]*AssertionError{}
+	for _, f := range failures {
+		paths[f.Path] = f
+	}
+	if f := paths["a"]; f == nil || f.Expected != "2" || f.Actual != "1" || f.Kind != KindJSONEquals {
+		t.Errorf("unexpected failure for path a: %+v", f)
+	}
+	if f := paths["b.c"]; f == nil || f.Expected != `"y"` || f.Actual != `"x"` {
+		t.Errorf("unexpected failure for path b.c: %+v", f)
+	}
+}
+
+func TestRenderAssertionErrors(t *testing.T) {
+	if got := RenderAssertionErrors(errors.New("plain"), false); got != "" {
+		t.Errorf("expected no report for non-assertion errors, got %q", got)
+	}
+	err := AssertionErrors{
+		textMismatch(KindOutputEquals, "output mismatch", "a\nb", "a\nc"),
+		&AssertionError{Kind: KindJSONEquals, Message: "mismatch at path x", Path: "x", Expected: "1", Actual: "2"},
+	}
+	plain := RenderAssertionErrors(err, false)
+	for _, want := range []string{"[output_equals] output mismatch", "    -b\n", "    +c\n", "[output_json_equals] x\n    - 1\n    + 2\n"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected %q in report:\n%s", want, plain)
+		}
+	}
+	if strings.Contains(plain, "\x1b[") {
+		t.Errorf("expected no color codes without color")
+	}
+	colored := RenderAssertionErrors(err, true)
+	if !strings.Contains(colored, ansiRed+"-b"+ansiReset) || !strings.Contains(colored, ansiGreen+"+c"+ansiReset) {
+		t.Errorf("expected colored diff lines, got:\n%s", colored)
+	}
+}
@@ -0,0 +1,99 @@
+package iapetus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AssertionFactory builds a parameterized assertion from the args given in YAML
+// (`- custom: <name>` with `args:`). It is called when the workflow is loaded, so
+// invalid args are reported before anything runs. args holds JSON-compatible values
+// (numbers are float64) and is nil if none were given; see DecodeAssertionArgs.
+type AssertionFactory func(args map[string]interface{}) (func(*Task) error, error)
+
+var (
+	assertionRegistryMu sync.RWMutex
+	assertionRegistry   = map[string]AssertionFactory{}
+)
+
+// RegisterAssertion registers a named assertion factory for use from YAML.
+// Returns an error if the name is empty, the factory is nil, or the name is already taken.
+//
+// Assertion libraries: call this in your package's init() function.
+func RegisterAssertion(name string, factory AssertionFactory) error {
+	if name == "" {
+		return fmt.Errorf("assertion name must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("assertion %s factory is nil", name)
+	}
+	assertionRegistryMu.Lock()
+	defer assertionRegistryMu.Unlock()
+	if _, exists := assertionRegistry[name]; exists {
+		return fmt.Errorf("assertion %s already registered", name)
+	}
+	assertionRegistry[name] = factory
+	return nil
+}
+
+// UnregisterAssertion removes a named assertion factory, if any.
+func UnregisterAssertion(name string) {
+	assertionRegistryMu.Lock()
+	defer assertionRegistryMu.Unlock()
+	delete(assertionRegistry, name)
+}
+
+// GetAssertion retrieves a named assertion factory, or nil if not found.
+func GetAssertion(name string) AssertionFactory {
+	assertionRegistryMu.RLock()
+	defer assertionRegistryMu.RUnlock()
+	return assertionRegistry[name]
+}
+
+// ListAssertions returns the names of all registered assertions in sorted order.
+func ListAssertions() []string {
+	assertionRegistryMu.RLock()
+	defer assertionRegistryMu.RUnlock()
+	names := make([]string, 0, len(assertionRegistry))
+	for name := range assertionRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAssertion builds a registered assertion by name with the given args.
+func NewAssertion(name string, args map[string]interface{}) (func(*Task) error, error) {
+	factory := GetAssertion(name)
+	if factory == nil {
+		return nil, fmt.Errorf("unknown custom assertion %q (registered: %v)", name, ListAssertions())
+	}
+	assert, err := factory(args)
+	if err != nil {
+		return nil, fmt.Errorf("custom assertion %s: %w", name, err)
+	}
+	if assert == nil {
+		return nil, fmt.Errorf("custom assertion %s: factory returned no assertion", name)
+	}
+	return assert, nil
+}
+
+// DecodeAssertionArgs decodes factory args into a struct (using its json tags), e.g.
+//
+//	var cfg struct {
+//	    Namespace string `json:"namespace"`
+//	    Min       int    `json:"min"`
+//	}
+//	if err := iapetus.DecodeAssertionArgs(args, &cfg); err != nil { return nil, err }
+func DecodeAssertionArgs(args map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("invalid args: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid args: %w", err)
+	}
+	return nil
+}
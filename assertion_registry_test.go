@@ -0,0 +1,113 @@
+package iapetus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// minLinesAssertion is a parameterized assertion used to exercise the registry.
+func minLinesAssertion(args map[string]interface{}) (func(*Task) error, error) {
+	var cfg struct {
+		Min int `json:"min"`
+	}
+	if err := DecodeAssertionArgs(args, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Min <= 0 {
+		return nil, fmt.Errorf("min must be positive")
+	}
+	return func(t *Task) error {
+		if n := len(strings.Split(normalizeOutput(t.Actual.Output), "\n")); n < cfg.Min {
+			return fmt.Errorf("expected at least %d lines, got %d", cfg.Min, n)
+		}
+		return nil
+	}, nil
+}
+
+func TestAssertionRegistry(t *testing.T) {
+	if err := RegisterAssertion("min_lines", minLinesAssertion); err != nil {
+		t.Fatalf("RegisterAssertion failed: %v", err)
+	}
+	t.Cleanup(func() { UnregisterAssertion("min_lines") })
+
+	if err := RegisterAssertion("min_lines", minLinesAssertion); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected duplicate registration error, got %v", err)
+	}
+	if err := RegisterAssertion("", minLinesAssertion); err == nil {
+		t.Errorf("expected error for empty name")
+	}
+	if err := RegisterAssertion("nil_factory", nil); err == nil {
+		t.Errorf("expected error for nil factory")
+	}
+	found := false
+	for _, name := range ListAssertions() {
+		if name == "min_lines" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected min_lines in %v", ListAssertions())
+	}
+
+	assert, err := NewAssertion("min_lines", map[string]interface{}{"min": 2.0})
+	if err != nil {
+		t.Fatalf("NewAssertion failed: %v", err)
+	}
+	if err := assert(&Task{Actual: Output{Output: "a\nb"}}); err != nil {
+		t.Errorf("expected pass, got %v", err)
+	}
+	if err := assert(&Task{Actual: Output{Output: "a"}}); err == nil {
+		t.Errorf("expected failure for one line")
+	}
+	if _, err := NewAssertion("min_lines", nil); err == nil || !strings.Contains(err.Error(), "min must be positive") {
+		t.Errorf("expected factory error, got %v", err)
+	}
+	if _, err := NewAssertion("nope", nil); err == nil || !strings.Contains(err.Error(), "unknown custom assertion") {
+		t.Errorf("expected unknown assertion error, got %v", err)
+	}
+}
+
+func TestLoadWorkflowFromYAML_CustomAssertion(t *testing.T) {
+	if err := RegisterAssertion("min_lines", minLinesAssertion); err != nil {
+		t.Fatalf("RegisterAssertion failed: %v", err)
+	}
+	t.Cleanup(func() { UnregisterAssertion("min_lines") })
+
+	path := writeTempYAML(t, `
+name: custom-wf
+steps:
+  - name: list
+    command: echo
+    raw_asserts:
+      - custom: min_lines
+        args: {min: 3}
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	task.Actual.Output = "a\nb\nc"
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected pass, got %v", err)
+	}
+	task.Actual.Output = "a"
+	if err := RunAssertions(task); err == nil {
+		t.Errorf("expected failure")
+	}
+
+	for name, assertion := range map[string]string{
+		"unknown":       `- custom: nope`,
+		"bad args":      `- {custom: min_lines, args: {min: 0}}`,
+		"args type":     `- {custom: min_lines, args: [1, 2]}`,
+		"args orphaned": `- {exit_code: 0, args: {min: 1}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeTempYAML(t, "name: bad\nsteps:\n  - name: s\n    command: echo\n    raw_asserts:\n      "+assertion+"\n")
+			if _, err := LoadWorkflowFromYAML(path); err == nil {
+				t.Errorf("expected load error")
+			}
+		})
+	}
+}
@@ -8,30 +8,46 @@
 // - Implement the Backend interface for your environment (e.g., Kubernetes, SSH, etc).
 // - Register your backend in an init() function using RegisterBackend.
 // - Tasks and workflows can select a backend by name.
+// - Or ship an iapetus-backend-<name> executable speaking JSON-RPC over stdio (see ServePlugin).
 //
 // # Built-in Backends
 //
 // - BashBackend: Runs tasks as local shell commands.
 // - DockerBackend: Runs tasks in Docker containers (requires task.Image).
+// - FuncBackend: Runs tasks as in-process Go functions (see RegisterTaskFunc).
+// - HTTPBackend: Sends HTTP requests described by task.HTTP.
 //
 // See the documentation for more details and examples.
 package iapetus
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-// init registers the built-in backends (bash, docker) at startup.
+// init registers the built-in backends at startup.
 func init() {
-	RegisterBackend("bash", &BashBackend{})
-	RegisterBackend("docker", &DockerBackend{})
-	RegisterBackend("kubernetes", &KubernetesBackend{})
+	for _, b := range []Backend{
+		&BashBackend{},
+		&DockerBackend{},
+		&KubernetesBackend{},
+		&FuncBackend{},
+		&HTTPBackend{},
+		&WorkflowBackend{},
+	} {
+		MustRegisterBackend(b.GetName(), b)
+	}
 }
 
 // Backend is the interface for task execution plugins.
@@ -40,6 +56,7 @@ func init() {
 // Register your backend with RegisterBackend.
 type Backend interface {
 	// RunTask executes the given task and populates its Actual fields.
+	// It should stop when task.Context() is done (the workflow timed out or was cancelled).
 	RunTask(task *Task) error
 	// ValidateTask checks if the task is valid for this backend.
 	ValidateTask(task *Task) error
@@ -49,24 +66,175 @@ type Backend interface {
 	GetStatus() string
 }
 
-// backendRegistry holds all registered backends by name.
-var backendRegistry = map[string]Backend{}
+// sendSignal delivers sig to cmd's process, or to its whole process group if
+// group is set and cmd was set up with setProcessGroup.
+var sendSignal = func(cmd *exec.Cmd, group bool, sig syscall.Signal) error {
+	if group {
+		return signalProcessGroup(cmd, sig)
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// runningCommands holds the commands set up by setGracefulCancel that have
+// started and not exited yet, so KillRunningTasks can reach them.
+var (
+	runningMu       sync.Mutex
+	runningCommands = map[*gracefulCommand]struct{}{}
+)
+
+// gracefulCommand is a command set up by setGracefulCancel. Start it with
+// start or output so it is tracked for KillRunningTasks, and call release once
+// it has exited.
+type gracefulCommand struct {
+	cmd   *exec.Cmd
+	group bool
+	// kill removes at once what the command started elsewhere (a container or
+	// a pod); it may be nil.
+	kill func() error
 
-// RegisterBackend registers a backend plugin by name.
+	mu      sync.Mutex
+	started bool
+	exited  bool
+	timer   *time.Timer
+}
+
+// setGracefulCancel controls how cmd, started with a context derived from
+// t.Context(), is stopped. If the task's own Timeout expires it is killed at once.
+// If the workflow is cancelled or times out, stop is called (by default the
+// process receives SIGTERM) and the process is killed if it has not exited
+// after the task's grace period (see Workflow.GracePeriod).
+// If group is set, cmd was set up with setProcessGroup and signals go to its
+// whole process group. kill, if not nil, force-removes what cmd started outside
+// its process group; it runs whenever cmd is killed without a grace period.
 //
-// Plugin authors: call this in your plugin's init() function.
-func RegisterBackend(name string, backend Backend) {
-	backendRegistry[name] = backend
+// The returned command's release method must be called once cmd's Wait
+// returns: it stops the pending kill, so no signal is sent to a process group
+// whose ID the system may have reused.
+func setGracefulCancel(cmd *exec.Cmd, t *Task, group bool, stop, kill func() error) *gracefulCommand {
+	c := &gracefulCommand{cmd: cmd, group: group, kill: kill}
+	cmd.Cancel = func() error {
+		if t.Context().Err() == nil {
+			return c.forceKill()
+		}
+		grace := t.gracePeriod()
+		t.Logger().Info("Stopping task", zap.String("task", t.Name), zap.Duration("grace_period", grace))
+		c.mu.Lock()
+		if !c.exited {
+			c.timer = time.AfterFunc(grace, func() { _ = c.signal(syscall.SIGKILL) })
+		}
+		c.mu.Unlock()
+		if stop != nil {
+			return stop()
+		}
+		return c.signal(syscall.SIGTERM)
+	}
+	return c
+}
+
+// signal sends sig to the command, unless it has not started or has exited.
+func (c *gracefulCommand) signal(sig syscall.Signal) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.started || c.exited {
+		return os.ErrProcessDone
+	}
+	return sendSignal(c.cmd, c.group, sig)
+}
+
+// forceKill kills the command without a grace period and waits for kill.
+func (c *gracefulCommand) forceKill() error {
+	err := c.signal(syscall.SIGKILL)
+	if c.kill != nil {
+		if kerr := c.kill(); kerr != nil && err == nil {
+			err = kerr
+		}
+	}
+	return err
 }
 
-// GetBackend retrieves a backend by name, or nil if not found.
-func GetBackend(name string) Backend {
-	if b, ok := backendRegistry[name]; ok {
-		return b
+// start starts the command and tracks it until release is called.
+func (c *gracefulCommand) start() error {
+	c.mu.Lock()
+	err := c.cmd.Start()
+	c.started = err == nil
+	c.mu.Unlock()
+	if err != nil {
+		return err
 	}
+	runningMu.Lock()
+	runningCommands[c] = struct{}{}
+	runningMu.Unlock()
 	return nil
 }
 
+// release marks the command as exited and stops the pending kill.
+func (c *gracefulCommand) release() {
+	runningMu.Lock()
+	delete(runningCommands, c)
+	runningMu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exited = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// output runs the command and returns its combined stdout and stderr, like
+// cmd.CombinedOutput, also copying it to t.OutputWriter as it is produced.
+func (c *gracefulCommand) output(t *Task) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if t.OutputWriter != nil {
+		w = io.MultiWriter(&buf, t.OutputWriter)
+	}
+	c.cmd.Stdout, c.cmd.Stderr = w, w
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+	err := c.cmd.Wait()
+	c.release()
+	return buf.Bytes(), err
+}
+
+// KillRunningTasks kills the commands started by the bash, docker, kubernetes
+// and plugin backends that are still running, without a grace period: their
+// process groups receive SIGKILL, containers are removed and pods deleted. It
+// returns once that is done. Use it before exiting on a forced shutdown (e.g. a
+// second Ctrl-C): the commands run in their own process groups, so signals sent
+// to the terminal's process group do not reach them.
+func KillRunningTasks() {
+	runningMu.Lock()
+	cmds := make([]*gracefulCommand, 0, len(runningCommands))
+	for c := range runningCommands {
+		cmds = append(cmds, c)
+	}
+	runningMu.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range cmds {
+		wg.Add(1)
+		go func(c *gracefulCommand) {
+			defer wg.Done()
+			_ = c.forceKill()
+		}(c)
+	}
+	wg.Wait()
+}
+
+// runHelper runs a command that stops or removes what a task started (e.g.
+// docker stop) in its own process group, so a Ctrl-C in the terminal does not
+// interrupt it.
+func runHelper(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	setProcessGroup(cmd)
+	return cmd.Run()
+}
+
+// graceSeconds formats a grace period as whole seconds for CLI flags.
+func graceSeconds(d time.Duration) string {
+	return fmt.Sprint(int(d.Round(time.Second) / time.Second))
+}
+
 // BashBackend runs tasks as local shell commands.
 type BashBackend struct{}
 
@@ -80,9 +248,13 @@ func (b *BashBackend) ValidateTask(t *Task) error {
 // Populates task.Actual.Output, ExitCode, and Error.
 func (b *BashBackend) RunTask(t *Task) error {
 	t.EnsureDefaults()
-	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout)
+	ctx, cancel := context.WithTimeout(t.Context(), t.Timeout)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, t.Command, t.Args...)
+	// Run the command in its own process group so a cancel also reaches the
+	// processes it spawns, which would otherwise keep its output open.
+	setProcessGroup(cmd)
+	gc := setGracefulCancel(cmd, t, true, nil, nil)
 
 	// Merge environment variables: os.Environ + t.Env + t.EnvMap (EnvMap takes precedence)
 	envMap := map[string]string{}
@@ -100,20 +272,36 @@ func (b *BashBackend) RunTask(t *Task) error {
 	for k, v := range envMap {
 		finalEnv = append(finalEnv, k+"="+v)
 	}
+	envFile, envErr := os.CreateTemp("", "iapetus-env-*")
+	if envErr == nil {
+		envFile.Close()
+		defer os.Remove(envFile.Name())
+		finalEnv = append(finalEnv, EnvFileVar+"="+envFile.Name())
+	}
 	cmd.Env = finalEnv
 
 	if t.WorkingDir != "" {
 		cmd.Dir = t.WorkingDir
 	}
 	t.Logger().Debug("Command", zap.String("cmd", t.Command+" "+strings.Join(t.Args, " ")))
-	output, err := cmd.CombinedOutput()
+	start := time.Now()
+	output, err := gc.output(t)
+	t.Actual.Duration = time.Since(start)
 	t.Actual.Output = string(output)
 	t.Actual.ExitCode = GetExitCode(err)
+	t.Actual.Env = nil
+	if envErr == nil {
+		t.Actual.Env = readEnvFile(envFile.Name())
+	}
 	if err != nil {
 		t.Actual.Error = err.Error()
-		if ctx.Err() == context.DeadlineExceeded {
+		if t.Context().Err() != nil {
+			t.Logger().Error("Task interrupted", zap.String("task", t.Name), zap.Error(context.Cause(t.Context())))
+			return t.contextError()
+		}
+		if ctx.Err() != nil {
 			t.Logger().Error("Task timed out", zap.String("task", t.Name), zap.Duration("timeout", t.Timeout))
-			return fmt.Errorf("task %s timed out after %v", t.Name, t.Timeout)
+			return t.contextError()
 		}
 		t.Logger().Error("Error executing task", zap.String("task", t.Name), zap.Error(err))
 	}
@@ -153,13 +341,16 @@ func (d *DockerBackend) ValidateTask(task *Task) error {
 
 // RunTask executes the task in a Docker container.
 // Passes environment variables, working directory, and arguments to the container.
+// If the workflow is cancelled or times out, the container is stopped with `docker stop`.
 // Populates task.Actual.Output, ExitCode, and Error.
 func (d *DockerBackend) RunTask(task *Task) error {
 	if err := d.ValidateTask(task); err != nil {
 		return err
 	}
 
-	dockerArgs := []string{"run", "--rm"}
+	// Name the container so it can be stopped if the workflow is cancelled.
+	name := "iapetus-" + uuid.New().String()
+	dockerArgs := []string{"run", "--rm", "--name", name}
 	if task.WorkingDir != "" {
 		dockerArgs = append(dockerArgs, "-w", task.WorkingDir)
 	}
@@ -170,8 +361,15 @@ func (d *DockerBackend) RunTask(task *Task) error {
 	dockerArgs = append(dockerArgs, task.Command)
 	dockerArgs = append(dockerArgs, task.Args...)
 
-	cmd := exec.Command("docker", dockerArgs...)
-	output, err := cmd.CombinedOutput()
+	cmd := exec.CommandContext(task.Context(), "docker", dockerArgs...)
+	gc := setGracefulCancel(cmd, task, false, func() error {
+		return runHelper("docker", "stop", "--time", graceSeconds(task.gracePeriod()), name)
+	}, func() error {
+		return runHelper("docker", "rm", "--force", name)
+	})
+	start := time.Now()
+	output, err := gc.output(task)
+	task.Actual.Duration = time.Since(start)
 	task.Actual.Output = string(output)
 	task.Actual.ExitCode = 0
 	if err != nil {
@@ -181,6 +379,9 @@ func (d *DockerBackend) RunTask(task *Task) error {
 		} else {
 			task.Actual.ExitCode = 1
 		}
+		if task.Context().Err() != nil {
+			return task.contextError()
+		}
 		return fmt.Errorf("docker run failed: %w\nOutput: %s", err, output)
 	}
 	// Run assertions and propagate errors
@@ -221,6 +422,7 @@ func (k *KubernetesBackend) ValidateTask(task *Task) error {
 // RunTask executes the task in a Kubernetes pod using kubectl.
 // For demo: uses 'kubectl run' and waits for completion.
 // Limitations: Only works if kubectl is installed and configured. Env vars are not injected via --env for simplicity.
+// If the workflow is cancelled or times out, the pod is deleted.
 func (k *KubernetesBackend) RunTask(task *Task) error {
 	if err := k.ValidateTask(task); err != nil {
 		return err
@@ -246,9 +448,16 @@ func (k *KubernetesBackend) RunTask(task *Task) error {
 		"--command", "--",
 		"sh", "-c", cmdStr,
 	}
-	cmd := exec.Command("kubectl", kubectlArgs...)
+	cmd := exec.CommandContext(task.Context(), "kubectl", kubectlArgs...)
+	gc := setGracefulCancel(cmd, task, false, func() error {
+		return runHelper("kubectl", "delete", "pod", podName, "--grace-period", graceSeconds(task.gracePeriod()), "--ignore-not-found")
+	}, func() error {
+		return runHelper("kubectl", "delete", "pod", podName, "--grace-period", "0", "--force", "--ignore-not-found")
+	})
 
-	output, err := cmd.CombinedOutput()
+	start := time.Now()
+	output, err := gc.output(task)
+	task.Actual.Duration = time.Since(start)
 	task.Actual.Output = string(output)
 	task.Actual.ExitCode = 0
 	if err != nil {
@@ -258,6 +467,9 @@ func (k *KubernetesBackend) RunTask(task *Task) error {
 		} else {
 			task.Actual.ExitCode = 1
 		}
+		if task.Context().Err() != nil {
+			return task.contextError()
+		}
 		return fmt.Errorf("kubectl run failed: %w\nOutput: %s", err, output)
 	}
 	// Run assertions and propagate errors
@@ -268,7 +480,10 @@ func (k *KubernetesBackend) RunTask(task *Task) error {
 	return nil
 }
 
+// GetName returns the backend name ("kubernetes").
 func (k *KubernetesBackend) GetName() string { return "kubernetes" }
+
+// GetStatus returns "available" if kubectl is installed, else "unavailable".
 func (k *KubernetesBackend) GetStatus() string {
 	if _, err := exec.LookPath("kubectl"); err == nil {
 		return "available"
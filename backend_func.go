@@ -0,0 +1,144 @@
+package iapetus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TaskFunc is an in-process task handler used by the "func" backend.
+//
+// The context is cancelled when the task timeout expires or the workflow is
+// cancelled. Handlers must honour it and must not use the task after they
+// return: the backend waits at most the grace period (see Workflow.GracePeriod)
+// for a cancelled handler before the task moves on, e.g. to its next attempt,
+// which reuses the same *Task. The returned Output becomes task.Actual.
+type TaskFunc func(ctx context.Context, t *Task) (Output, error)
+
+var (
+	taskFuncsMu sync.RWMutex
+	// taskFuncs holds all registered task functions by name.
+	taskFuncs = map[string]TaskFunc{}
+)
+
+// RegisterTaskFunc registers a named Go handler for the "func" backend.
+//
+// YAML steps reference it with `backend: func` and `command: <name>`.
+func RegisterTaskFunc(name string, fn TaskFunc) {
+	taskFuncsMu.Lock()
+	defer taskFuncsMu.Unlock()
+	taskFuncs[name] = fn
+}
+
+// GetTaskFunc retrieves a task function by name, or nil if not found.
+func GetTaskFunc(name string) TaskFunc {
+	taskFuncsMu.RLock()
+	defer taskFuncsMu.RUnlock()
+	return taskFuncs[name]
+}
+
+// FuncBackend runs tasks as in-process Go functions instead of forking a process.
+//
+// A task is resolved to a function either directly (task.Func, see Task.SetFunc)
+// or by looking up task.Command in the functions registered with RegisterTaskFunc.
+type FuncBackend struct{}
+
+// ValidateTask checks that the task resolves to a function.
+func (f *FuncBackend) ValidateTask(t *Task) error {
+	_, err := f.resolve(t)
+	return err
+}
+
+// resolve returns the function for the task, preferring task.Func over the registry.
+func (f *FuncBackend) resolve(t *Task) (TaskFunc, error) {
+	if t.Func != nil {
+		return t.Func, nil
+	}
+	if t.Command == "" {
+		return nil, fmt.Errorf("func backend requires task.Func or task.Command to be set")
+	}
+	fn := GetTaskFunc(t.Command)
+	if fn == nil {
+		return nil, fmt.Errorf("func backend: no function registered as %q", t.Command)
+	}
+	return fn, nil
+}
+
+// RunTask calls the task function with a context bounded by task.Timeout.
+// Populates task.Actual from the returned Output and runs assertions.
+// If the context is done first, it waits up to the task's grace period for
+// the function to return.
+func (f *FuncBackend) RunTask(t *Task) error {
+	t.EnsureDefaults()
+	fn, err := f.resolve(t)
+	if err != nil {
+		return err
+	}
+	if t.Timeout == 0 {
+		t.Timeout = DefaultTaskTimeout
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), t.Timeout)
+	defer cancel()
+
+	type result struct {
+		out Output
+		err error
+	}
+	resCh := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				resCh <- result{out: Output{ExitCode: 1}, err: fmt.Errorf("panic in task function: %v", r)}
+			}
+		}()
+		out, err := fn(ctx, t)
+		resCh <- result{out: out, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-resCh:
+	case <-ctx.Done():
+		// Let the handler return before the task is reused by the next attempt.
+		select {
+		case <-resCh:
+		case <-time.After(t.gracePeriod()):
+			t.Logger().Warn("Task function ignored cancellation", zap.String("task", t.Name), zap.Duration("grace_period", t.gracePeriod()))
+		}
+		t.Actual = Output{ExitCode: -1, Error: ctx.Err().Error(), Duration: time.Since(start)}
+		t.Logger().Error("Task timed out", zap.String("task", t.Name), zap.Duration("timeout", t.Timeout))
+		return t.contextError()
+	}
+
+	t.Actual = res.out
+	t.Actual.Duration = time.Since(start)
+	if res.err != nil {
+		t.Actual.Error = res.err.Error()
+		if t.Actual.ExitCode == 0 {
+			t.Actual.ExitCode = 1
+		}
+		t.Logger().Error("Error executing task function", zap.String("task", t.Name), zap.Error(res.err))
+		return fmt.Errorf("task function failed: %w", res.err)
+	}
+	// Run assertions and propagate errors
+	err = RunAssertions(t)
+	if err != nil {
+		t.Logger().Error("Assertion(s) failed", zap.String("task", t.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetName returns the backend name ("func").
+func (f *FuncBackend) GetName() string {
+	return "func"
+}
+
+// GetStatus returns "available" for FuncBackend.
+func (f *FuncBackend) GetStatus() string {
+	return "available"
+}
@@ -0,0 +1,135 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestFuncBackend_RegisteredFunc(t *testing.T) {
+	RegisterTaskFunc("greet", func(ctx context.Context, task *Task) (Output, error) {
+		return Output{Output: "hello " + task.EnvMap["WHO"]}, nil
+	})
+	task := NewTask("greet", time.Second, zap.NewNop())
+	task.Command = "greet"
+	task.EnvMap = map[string]string{"WHO": "world"}
+	task.SetBackend("func")
+	task.AssertExitCode(0).AssertOutputEquals("hello world")
+	if err := task.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestFuncBackend_SetFunc(t *testing.T) {
+	calls := 0
+	task := NewTask("inline", time.Second, zap.NewNop()).
+		SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			calls++
+			if calls < 2 {
+				return Output{Output: "not ready"}, nil
+			}
+			return Output{Output: "ready"}, nil
+		}).
+		SetRetries(3).
+		SetRetryDelay(time.Millisecond).
+		AssertOutputEquals("ready")
+	if task.Backend != "func" {
+		t.Fatalf("expected backend 'func', got %q", task.Backend)
+	}
+	if err := task.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestFuncBackend_ValidateTask(t *testing.T) {
+	b := &FuncBackend{}
+	task := NewTask("test", time.Second, zap.NewNop())
+	if err := b.ValidateTask(task); err == nil {
+		t.Errorf("expected error for task without function")
+	}
+	task.Command = "not-registered"
+	if err := b.ValidateTask(task); err == nil || !strings.Contains(err.Error(), "not-registered") {
+		t.Errorf("expected unregistered function error, got %v", err)
+	}
+}
+
+func TestFuncBackend_Error(t *testing.T) {
+	task := NewTask("fails", time.Second, zap.NewNop()).
+		SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			return Output{}, errors.New("boom")
+		})
+	err := (&FuncBackend{}).RunTask(task)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected function error, got %v", err)
+	}
+	if task.Actual.ExitCode != 1 || task.Actual.Error != "boom" {
+		t.Errorf("unexpected actual: %+v", task.Actual)
+	}
+}
+
+func TestFuncBackend_Timeout(t *testing.T) {
+	task := NewTask("slow", 50*time.Millisecond, zap.NewNop()).
+		SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			<-ctx.Done()
+			return Output{}, ctx.Err()
+		})
+	if err := (&FuncBackend{}).RunTask(task); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestFuncBackend_WaitsForCancelledHandler(t *testing.T) {
+	// The handler cleans up and writes to the task after its context is done;
+	// the next attempt must not start before it returns (see go test -race).
+	attempts := 0
+	task := NewTask("cleanup", 20*time.Millisecond, zap.NewNop()).
+		SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			attempts++
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			task.EnvMap["CLEANED"] = "yes"
+			return Output{}, ctx.Err()
+		}).
+		SetRetries(2).
+		SetRetryDelay(time.Millisecond)
+	if err := task.Run(); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if attempts != 2 || task.EnvMap["CLEANED"] != "yes" {
+		t.Errorf("expected 2 attempts that cleaned up, got %d and %v", attempts, task.EnvMap)
+	}
+
+	// A handler that ignores its context is abandoned after the grace period.
+	stuck := NewTask("stuck", 10*time.Millisecond, zap.NewNop()).
+		SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			time.Sleep(time.Second)
+			return Output{}, nil
+		})
+	stuck.parent = NewWorkflow("w", zap.NewNop()).SetGracePeriod(20 * time.Millisecond)
+	start := time.Now()
+	if err := (&FuncBackend{}).RunTask(stuck); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the handler to be abandoned after the grace period, took %v", elapsed)
+	}
+}
+
+func TestFuncBackend_InWorkflow(t *testing.T) {
+	RegisterTaskFunc("check", func(ctx context.Context, task *Task) (Output, error) {
+		return Output{Output: `{"status":"ok"}`}, nil
+	})
+	w := NewWorkflow("func-wf", zap.NewNop())
+	w.AddTask(Task{Name: "native", Command: "check", Backend: "func"})
+	w.Steps[0].AssertOutputJsonEquals(`{"status":"ok"}`)
+	if err := w.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package iapetus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPRequest describes the request sent by the "http" backend.
+type HTTPRequest struct {
+	// Method is the HTTP method. Defaults to GET.
+	Method string `json:"method" yaml:"method,omitempty"`
+	// URL is the request URL (required).
+	URL string `json:"url" yaml:"url"`
+	// Headers are added to the request.
+	Headers map[string]string `json:"headers" yaml:"headers,omitempty"`
+	// Body is the request body (optional).
+	Body string `json:"body" yaml:"body,omitempty"`
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool `json:"insecure_skip_verify" yaml:"insecure_skip_verify,omitempty"`
+	// CACertFile is a PEM file with additional root CAs to trust (optional).
+	CACertFile string `json:"ca_file" yaml:"ca_file,omitempty"`
+}
+
+// HTTPBackend runs tasks as HTTP requests described by task.HTTP.
+//
+// The response body is stored in task.Actual.Output, the status code in
+// task.Actual.StatusCode and the response headers in task.Actual.Headers.
+// ExitCode is 0 whenever a response was received, regardless of status.
+type HTTPBackend struct{}
+
+// ValidateTask checks that task.HTTP is set with a valid absolute URL.
+func (h *HTTPBackend) ValidateTask(task *Task) error {
+	if task.HTTP == nil {
+		return fmt.Errorf("http backend requires task.HTTP to be set")
+	}
+	if task.HTTP.URL == "" {
+		return fmt.Errorf("http backend requires task.HTTP.URL to be set")
+	}
+	u, err := url.Parse(task.HTTP.URL)
+	if err != nil {
+		return fmt.Errorf("http backend: invalid URL %q: %w", task.HTTP.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("http backend: unsupported URL scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// RunTask sends the request, populates task.Actual and runs assertions.
+func (h *HTTPBackend) RunTask(task *Task) error {
+	task.EnsureDefaults()
+	if err := h.ValidateTask(task); err != nil {
+		return err
+	}
+	if task.Timeout == 0 {
+		task.Timeout = DefaultTaskTimeout
+	}
+	client, err := h.client(task.HTTP)
+	if err != nil {
+		return err
+	}
+	if client != http.DefaultClient {
+		// The transport is per task; close its connections once the body is read.
+		defer client.CloseIdleConnections()
+	}
+	ctx, cancel := context.WithTimeout(task.Context(), task.Timeout)
+	defer cancel()
+
+	method := task.HTTP.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if task.HTTP.Body != "" {
+		body = strings.NewReader(task.HTTP.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), task.HTTP.URL, body)
+	if err != nil {
+		return fmt.Errorf("http backend: failed to build request: %w", err)
+	}
+	for k, v := range task.HTTP.Headers {
+		req.Header.Set(k, v)
+	}
+	task.Logger().Debug("HTTP request", zap.String("method", req.Method), zap.String("url", task.HTTP.URL))
+
+	task.Actual = Output{}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		task.Actual.Duration = time.Since(start)
+		task.Actual.ExitCode = -1
+		task.Actual.Error = err.Error()
+		if ctx.Err() != nil {
+			task.Logger().Error("Task timed out", zap.String("task", task.Name), zap.Duration("timeout", task.Timeout))
+			return task.contextError()
+		}
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	task.Actual.Duration = time.Since(start)
+	task.Actual.Output = string(data)
+	task.Actual.StatusCode = resp.StatusCode
+	task.Actual.Headers = resp.Header
+	if err != nil {
+		task.Actual.ExitCode = -1
+		task.Actual.Error = err.Error()
+		return fmt.Errorf("http backend: failed to read response body: %w", err)
+	}
+	// Run assertions and propagate errors
+	err = RunAssertions(task)
+	if err != nil {
+		task.Logger().Error("Assertion(s) failed", zap.String("task", task.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// client builds an HTTP client honouring the request's TLS options.
+// With TLS options set it returns a new client with its own transport, whose
+// idle connections the caller must close.
+func (h *HTTPBackend) client(r *HTTPRequest) (*http.Client, error) {
+	if !r.InsecureSkipVerify && r.CACertFile == "" {
+		return http.DefaultClient, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: r.InsecureSkipVerify} //nolint:gosec // opt-in per task
+	if r.CACertFile != "" {
+		pem, err := os.ReadFile(r.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("http backend: failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("http backend: no certificates found in %s", r.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// GetName returns the backend name ("http").
+func (h *HTTPBackend) GetName() string {
+	return "http"
+}
+
+// GetStatus returns "available" for HTTPBackend.
+func (h *HTTPBackend) GetStatus() string {
+	return "available"
+}
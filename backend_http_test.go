@@ -0,0 +1,162 @@
+package iapetus
+
+import (
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestHTTPServer(t *testing.T, tls bool) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Method", r.Method)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"token":"` + r.Header.Get("X-Token") + `","body":` + string(body) + `}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	var srv *httptest.Server
+	if tls {
+		srv = httptest.NewTLSServer(handler)
+	} else {
+		srv = httptest.NewServer(handler)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPBackend_ValidateTask(t *testing.T) {
+	b := &HTTPBackend{}
+	task := NewTask("test", 0, zap.NewNop())
+	if err := b.ValidateTask(task); err == nil {
+		t.Errorf("expected error for missing HTTP request")
+	}
+	task.HTTP = &HTTPRequest{}
+	if err := b.ValidateTask(task); err == nil || !strings.Contains(err.Error(), "URL") {
+		t.Errorf("expected URL error, got %v", err)
+	}
+	task.HTTP.URL = "ftp://example.com"
+	if err := b.ValidateTask(task); err == nil || !strings.Contains(err.Error(), "scheme") {
+		t.Errorf("expected scheme error, got %v", err)
+	}
+	task.HTTP.URL = "http://example.com"
+	if err := b.ValidateTask(task); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestHTTPBackend_RunTask(t *testing.T) {
+	srv := newTestHTTPServer(t, false)
+	task := NewTask("post", 2*time.Second, zap.NewNop()).
+		SetHTTP(&HTTPRequest{
+			Method:  "post",
+			URL:     srv.URL + "/echo",
+			Headers: map[string]string{"X-Token": "abc"},
+			Body:    `{"items":[{"name":"a"},{"name":"b"}]}`,
+		}).
+		Expect().
+		StatusCode(http.StatusCreated).
+		HeaderEquals("content-type", "application/json").
+		HeaderEquals("X-Method", "POST").
+		JSONPathEquals("token", "abc").
+		JSONPathEquals("$.body.items[1].name", "b").
+		Done()
+	if err := task.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.Actual.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", task.Actual.ExitCode)
+	}
+}
+
+func TestHTTPBackend_StatusMismatch(t *testing.T) {
+	srv := newTestHTTPServer(t, false)
+	task := NewTask("missing", 2*time.Second, zap.NewNop()).
+		SetHTTP(&HTTPRequest{URL: srv.URL + "/missing"}).
+		AssertStatusCode(http.StatusOK)
+	err := (&HTTPBackend{}).RunTask(task)
+	if err == nil || !strings.Contains(err.Error(), "expected 200, got 404") {
+		t.Errorf("expected status code mismatch, got %v", err)
+	}
+}
+
+func TestHTTPBackend_Timeout(t *testing.T) {
+	srv := newTestHTTPServer(t, false)
+	task := NewTask("slow", 50*time.Millisecond, zap.NewNop()).
+		SetHTTP(&HTTPRequest{URL: srv.URL + "/slow"})
+	err := (&HTTPBackend{}).RunTask(task)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestHTTPBackend_TLS(t *testing.T) {
+	srv := newTestHTTPServer(t, true)
+	task := NewTask("tls", 2*time.Second, zap.NewNop()).
+		SetHTTP(&HTTPRequest{URL: srv.URL + "/missing"}).
+		AssertStatusCode(http.StatusNotFound)
+	if err := (&HTTPBackend{}).RunTask(task); err == nil {
+		t.Errorf("expected certificate error without TLS options")
+	}
+
+	task.HTTP.InsecureSkipVerify = true
+	if err := (&HTTPBackend{}).RunTask(task); err != nil {
+		t.Errorf("expected no error with insecure_skip_verify, got %v", err)
+	}
+
+	f, err := os.CreateTemp("", "iapetus_ca_*.pem")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if err := pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	f.Close()
+	task.HTTP.InsecureSkipVerify = false
+	task.HTTP.CACertFile = f.Name()
+	if err := (&HTTPBackend{}).RunTask(task); err != nil {
+		t.Errorf("expected no error with ca_file, got %v", err)
+	}
+}
+
+func TestHTTPBackend_TLSClosesConnections(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	task := NewTask("tls", 2*time.Second, zap.NewNop()).
+		SetHTTP(&HTTPRequest{URL: srv.URL, InsecureSkipVerify: true}).
+		AssertStatusCode(http.StatusNotFound)
+	if err := (&HTTPBackend{}).RunTask(task); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Errorf("expected the per-task connection to be closed after the request")
+	}
+}
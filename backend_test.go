@@ -1,6 +1,7 @@
 package iapetus
 
 import (
+	"bytes"
 	"errors"
 	"os/exec"
 	"strings"
@@ -28,7 +29,11 @@ func (m *mockBackend) GetStatus() string             { return m.status }
 
 func TestRegisterAndGetBackend(t *testing.T) {
 	mb := &mockBackend{name: "mock", status: "available"}
-	RegisterBackend("mock", mb)
+	UnregisterBackend("mock")
+	if err := RegisterBackend("mock", mb); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() { UnregisterBackend("mock") })
 	b := GetBackend("mock")
 	if b == nil {
 		t.Fatal("expected backend to be registered and retrievable")
@@ -185,6 +190,39 @@ func TestBashBackend_RunTask_EnvMap(t *testing.T) {
 	}
 }
 
+func TestBashBackend_RunTask_OutputWriter(t *testing.T) {
+	b := &BashBackend{}
+	task := NewTask("test", 2*time.Second, zap.NewNop())
+	task.Command = "sh"
+	task.Args = []string{"-c", "echo out; echo err >&2"}
+	var streamed bytes.Buffer
+	task.OutputWriter = &streamed
+	if err := b.RunTask(task); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.Actual.Output != "out\nerr\n" || streamed.String() != task.Actual.Output {
+		t.Errorf("expected output to be captured and streamed, got %q and %q", task.Actual.Output, streamed.String())
+	}
+}
+
+func TestBashBackend_RunTask_SideEffects(t *testing.T) {
+	b := &BashBackend{}
+	dir := t.TempDir()
+	task := NewTask("test", 2*time.Second, zap.NewNop())
+	task.Command = "sh"
+	task.Args = []string{"-c", `echo built > artifact.txt && echo "VERSION=1.2.3" >> "$IAPETUS_ENV"`}
+	task.WorkingDir = dir
+	task.AssertFileContains("artifact.txt", "built").
+		AssertEnvEquals("VERSION", "1.2.3").
+		AssertDurationUnder(2 * time.Second)
+	if err := b.RunTask(task); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.Actual.Duration <= 0 {
+		t.Errorf("expected duration to be recorded, got %v", task.Actual.Duration)
+	}
+}
+
 func TestBashBackend_RunTask_Timeout(t *testing.T) {
 	b := &BashBackend{}
 	task := NewTask("test", 500*time.Millisecond, zap.NewNop())
@@ -0,0 +1,24 @@
+//go:build unix
+
+package iapetus
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group, so that signals also
+// reach the processes it spawns.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends sig to the process group started by setProcessGroup.
+// Once the group leader has been waited for, its ID may be reused, so nothing
+// is sent and os.ErrProcessDone is returned.
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+		return err
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
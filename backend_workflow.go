@@ -0,0 +1,197 @@
+package iapetus
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxWorkflowDepth bounds sub-workflow nesting, so a YAML file that includes
+// itself fails instead of recursing forever.
+const maxWorkflowDepth = 16
+
+// SubWorkflow describes another workflow run as a single task (see Task.Workflow).
+type SubWorkflow struct {
+	// Workflow is the workflow to run. It is copied for every run, so it can be
+	// shared between tasks.
+	Workflow *Workflow `json:"-" yaml:"-"`
+	// Path is a workflow YAML file, loaded when the task runs. Used if Workflow is nil.
+	// A relative path is resolved against the process working directory; paths
+	// read by LoadWorkflowFromYAML are first made relative to the YAML file.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Params are added to the environment of every step of the sub-workflow
+	// (without overriding variables the steps set themselves).
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+	// Outputs selects the variables exported to the parent task's Actual.Env,
+	// mapping a parent variable name to "<step>.<VAR>", a variable the step
+	// exported via $IAPETUS_ENV. The reference is split on its last dot, so step
+	// names may contain dots. If empty, every exported variable is passed up.
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+// WorkflowBackend runs tasks whose Workflow is set as nested workflows.
+//
+// The nested run's result is stored in task.Actual.Workflow and its exported
+// variables (see SubWorkflow.Outputs) in task.Actual.Env. ExitCode is 0 if the
+// nested workflow succeeded and 1 otherwise; a failure is returned as the nested
+// *WorkflowError, identifying the inner failing step.
+type WorkflowBackend struct{}
+
+// ValidateTask checks that the task names a workflow and its outputs are well-formed.
+func (b *WorkflowBackend) ValidateTask(task *Task) error {
+	sw := task.Workflow
+	if sw == nil || (sw.Workflow == nil && sw.Path == "") {
+		return fmt.Errorf("workflow backend requires task.Workflow with a Workflow or Path")
+	}
+	if sw.Workflow == nil {
+		if _, err := os.Stat(sw.Path); err != nil {
+			return fmt.Errorf("workflow backend: %w", err)
+		}
+	}
+	for name, ref := range sw.Outputs {
+		if step, v, ok := splitOutputRef(ref); !ok || step == "" || v == "" {
+			return fmt.Errorf("workflow backend: output %s must reference <step>.<VAR>, got %q", name, ref)
+		}
+	}
+	return nil
+}
+
+// RunTask runs the nested workflow, populates task.Actual and runs assertions.
+func (b *WorkflowBackend) RunTask(task *Task) error {
+	task.EnsureDefaults()
+	if err := b.ValidateTask(task); err != nil {
+		return err
+	}
+	if task.parent != nil && task.parent.depth >= maxWorkflowDepth {
+		return fmt.Errorf("workflow backend: sub-workflows nested deeper than %d levels", maxWorkflowDepth)
+	}
+	inner, err := b.load(task)
+	if err != nil {
+		return err
+	}
+	task.Actual = Output{}
+	start := time.Now()
+	runErr := inner.RunContext(task.Context())
+	task.Actual.Duration = time.Since(start)
+	task.Actual.Workflow = inner.Result()
+	task.Actual.Env = subWorkflowOutputs(inner, task.Workflow.Outputs)
+	if runErr != nil {
+		task.Actual.ExitCode = 1
+		task.Actual.Error = runErr.Error()
+		task.Logger().Error("Sub-workflow failed", zap.String("task", task.Name), zap.Error(runErr))
+		return runErr
+	}
+	if err := RunAssertions(task); err != nil {
+		task.Logger().Error("Assertion(s) failed", zap.String("task", task.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// load returns a fresh copy of the task's workflow, set up to run nested in it.
+func (b *WorkflowBackend) load(task *Task) (*Workflow, error) {
+	sw := task.Workflow
+	var inner *Workflow
+	if sw.Workflow != nil {
+		copied := *sw.Workflow
+		copied.Steps = append([]Task(nil), sw.Workflow.Steps...)
+		copied.result, copied.state, copied.resume = nil, nil, nil
+		inner = &copied
+		if inner.logger == nil {
+			inner.logger = task.Logger()
+		}
+	} else {
+		loaded, err := LoadWorkflowFromYAML(sw.Path)
+		if err != nil {
+			return nil, fmt.Errorf("workflow backend: %w", err)
+		}
+		inner = loaded
+		inner.logger = task.Logger()
+	}
+	if inner.backends == nil || len(inner.backends.List()) == 0 {
+		inner.backends = task.backends
+	}
+	if inner.Backend == "" {
+		inner.Backend = DefaultBackend
+	}
+	inner.RunID = ""
+	inner.StateDir = ""
+	if p := task.parent; p != nil {
+		inner.depth = p.depth + 1
+		if inner.CacheDir == "" {
+			inner.CacheDir = p.CacheDir
+		}
+		if inner.GracePeriod == 0 {
+			inner.GracePeriod = p.GracePeriod
+		}
+	}
+	if len(sw.Params) > 0 {
+		inner.EnvMap = mergeEnv(inner.EnvMap, sw.Params)
+		for i := range inner.Steps {
+			if len(inner.Steps[i].EnvMap) > 0 {
+				inner.Steps[i].EnvMap = mergeEnv(inner.Steps[i].EnvMap, sw.Params)
+			}
+		}
+	}
+	return inner, nil
+}
+
+// mergeEnv returns a copy of env with the params it does not set added.
+func mergeEnv(env, params map[string]string) map[string]string {
+	out := make(map[string]string, len(env)+len(params))
+	for k, v := range params {
+		out[k] = v
+	}
+	for k, v := range env {
+		out[k] = v
+	}
+	return out
+}
+
+// subWorkflowOutputs collects the variables a nested workflow exports to its parent.
+func subWorkflowOutputs(inner *Workflow, outputs map[string]string) map[string]string {
+	steps := make(map[string]*Task, len(inner.Steps))
+	for i := range inner.Steps {
+		steps[inner.Steps[i].Name] = &inner.Steps[i]
+	}
+	env := make(map[string]string)
+	if len(outputs) == 0 {
+		for i := range inner.Steps {
+			for k, v := range inner.Steps[i].Actual.Env {
+				env[k] = v
+			}
+		}
+		return env
+	}
+	for name, ref := range outputs {
+		step, v, _ := splitOutputRef(ref)
+		if t, ok := steps[step]; ok {
+			if val, ok := t.Actual.Env[v]; ok {
+				env[name] = val
+			}
+		}
+	}
+	return env
+}
+
+// splitOutputRef splits a "<step>.<VAR>" output reference on its last dot.
+func splitOutputRef(ref string) (step, v string, ok bool) {
+	i := strings.LastIndex(ref, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	return ref[:i], ref[i+1:], true
+}
+
+// GetName returns the backend name ("workflow").
+func (b *WorkflowBackend) GetName() string {
+	return "workflow"
+}
+
+// GetStatus returns "available" for WorkflowBackend.
+func (b *WorkflowBackend) GetStatus() string {
+	return "available"
+}
@@ -0,0 +1,196 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// dbWorkflow builds a two-step workflow whose "migrate" step exports DB_URL
+// built from the DB_VERSION param, and fails if fail is set.
+func dbWorkflow(fail bool) *Workflow {
+	w := NewWorkflow("db", zap.NewNop())
+	w.AddTask(*NewTask("provision", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			return Output{Env: map[string]string{"HOST": "db.local"}}, nil
+		}))
+	migrate := NewTask("migrate", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			if fail {
+				return Output{ExitCode: 1}, errors.New("migration failed")
+			}
+			return Output{Env: map[string]string{"DB_URL": "postgres://db.local/" + t.EnvMap["DB_VERSION"]}}, nil
+		})
+	migrate.Depends = []string{"provision"}
+	w.AddTask(*migrate)
+	return w
+}
+
+func TestWorkflowBackend_GoWorkflow(t *testing.T) {
+	inner := dbWorkflow(false)
+	w := NewWorkflow("app", zap.NewNop())
+	w.AddTask(*NewTask("deploy-db", 0, zap.NewNop()).
+		SetWorkflow(&SubWorkflow{
+			Workflow: inner,
+			Params:   map[string]string{"DB_VERSION": "15"},
+			Outputs:  map[string]string{"DB_URL": "migrate.DB_URL"},
+		}).
+		AddAssertion(AssertEnvEquals("DB_URL", "postgres://db.local/15")))
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, map[string]string{"DB_URL": "postgres://db.local/15"}, w.Steps[0].Actual.Env)
+
+	res := w.Result().Task("deploy-db")
+	if res == nil || res.Workflow == nil {
+		t.Fatalf("expected nested result, got %+v", res)
+	}
+	assert.Equal(t, "db", res.Workflow.Workflow)
+	assert.Equal(t, TaskStatusSucceeded, res.Workflow.Task("migrate").Status)
+
+	// The shared Go workflow is copied, not run in place.
+	if inner.Result() != nil || inner.Steps[1].Actual.Env != nil {
+		t.Errorf("expected the original workflow to be left untouched")
+	}
+
+	// Without Outputs every exported variable is passed up.
+	w = NewWorkflow("app", zap.NewNop())
+	w.AddTask(*NewTask("deploy-db", 0, zap.NewNop()).
+		SetWorkflow(&SubWorkflow{Workflow: inner, Params: map[string]string{"DB_VERSION": "16"}}))
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, map[string]string{"HOST": "db.local", "DB_URL": "postgres://db.local/16"}, w.Steps[0].Actual.Env)
+}
+
+func TestWorkflowBackend_DottedStepOutputs(t *testing.T) {
+	inner := NewWorkflow("release", zap.NewNop())
+	inner.AddTask(*NewTask("build.v2", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			return Output{Env: map[string]string{"IMAGE": "app:v2"}}, nil
+		}))
+	w := NewWorkflow("app", zap.NewNop())
+	w.AddTask(*NewTask("release", 0, zap.NewNop()).
+		SetWorkflow(&SubWorkflow{Workflow: inner, Outputs: map[string]string{"IMAGE": "build.v2.IMAGE"}}))
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, map[string]string{"IMAGE": "app:v2"}, w.Steps[0].Actual.Env)
+}
+
+func TestWorkflowBackend_FailureChain(t *testing.T) {
+	w := NewWorkflow("app", zap.NewNop())
+	w.AddTask(*NewTask("deploy-db", 0, zap.NewNop()).SetWorkflow(&SubWorkflow{Workflow: dbWorkflow(true)}))
+	err := w.Run()
+	var wfErr *WorkflowError
+	if !errors.As(err, &wfErr) {
+		t.Fatalf("expected WorkflowError, got %v", err)
+	}
+	assert.Equal(t, []string{"deploy-db", "migrate"}, wfErr.FailedSteps())
+	if !strings.Contains(err.Error(), "migration failed") {
+		t.Errorf("expected inner error in %q", err.Error())
+	}
+	res := w.Result().Task("deploy-db")
+	assert.Equal(t, TaskStatusFailed, res.Status)
+	assert.Equal(t, TaskStatusFailed, res.Workflow.Task("migrate").Status)
+	assert.Equal(t, TaskStatusSucceeded, res.Workflow.Task("provision").Status)
+}
+
+func TestWorkflowBackend_YAML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "workflows"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	inner := `
+name: inner
+steps:
+  - name: greet
+    command: echo
+    args: ["hello"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "workflows", "inner.yaml"), []byte(inner), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outer := filepath.Join(dir, "outer.yaml")
+	content := `
+name: outer
+steps:
+  - name: sub
+    workflow:
+      path: workflows/inner.yaml
+      params: {GREETING: hi}
+`
+	if err := os.WriteFile(outer, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWorkflowFromYAML(outer)
+	if err != nil {
+		t.Fatalf("failed to load workflow: %v", err)
+	}
+	w.logger = zap.NewNop()
+	task := w.Steps[0]
+	assert.Equal(t, "workflow", task.Backend)
+	assert.Equal(t, filepath.Join(dir, "workflows", "inner.yaml"), task.Workflow.Path)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nested := w.Result().Task("sub").Workflow
+	if nested == nil || nested.Task("greet").Status != TaskStatusSucceeded {
+		t.Fatalf("expected nested greet step to succeed, got %+v", nested)
+	}
+}
+
+func TestWorkflowBackend_DepthLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "loop.yaml")
+	content := `
+name: loop
+steps:
+  - name: again
+    workflow: {path: loop.yaml}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("failed to load workflow: %v", err)
+	}
+	w.logger = zap.NewNop()
+	err = w.Run()
+	if err == nil || !strings.Contains(err.Error(), "nested deeper than") {
+		t.Fatalf("expected depth limit error, got %v", err)
+	}
+	var wfErr *WorkflowError
+	if errors.As(err, &wfErr) && len(wfErr.FailedSteps()) != maxWorkflowDepth+1 {
+		t.Errorf("expected %d nested steps, got %d", maxWorkflowDepth+1, len(wfErr.FailedSteps()))
+	}
+}
+
+func TestWorkflowBackend_ValidateTask(t *testing.T) {
+	b := &WorkflowBackend{}
+	tests := []struct {
+		name string
+		sw   *SubWorkflow
+		want string
+	}{
+		{"missing", nil, "requires task.Workflow"},
+		{"empty", &SubWorkflow{}, "requires task.Workflow"},
+		{"no file", &SubWorkflow{Path: filepath.Join(t.TempDir(), "none.yaml")}, "no such file"},
+		{"bad output", &SubWorkflow{Workflow: dbWorkflow(false), Outputs: map[string]string{"X": "migrate"}}, "<step>.<VAR>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.ValidateTask(&Task{Name: "sub", Workflow: tt.sw})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package iapetus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCacheDir is the directory, relative to the working directory, where the
+// CLI stores cached task results.
+const DefaultCacheDir = ".iapetus/cache"
+
+// CacheStatus reports whether a cached task was restored from the cache.
+type CacheStatus string
+
+const (
+	// CacheHit means the task's recorded output was restored and it did not run.
+	CacheHit CacheStatus = "hit"
+	// CacheMiss means the task ran because no usable cache entry existed.
+	CacheMiss CacheStatus = "miss"
+)
+
+// CacheOptions opts a task into result caching. The cache key covers the task's
+// command, args, environment, image, working dir, backend, HTTP request and
+// sub-workflow path and params, plus the content of every file matched by Inputs.
+// A handler set with SetFunc cannot be hashed, so such tasks are also keyed on
+// their name: changing the handler's code does not invalidate the entry, so list
+// the files it depends on in Inputs or register it with RegisterTaskFunc.
+type CacheOptions struct {
+	// Inputs are file globs (relative to the task's WorkingDir) whose content is
+	// part of the cache key. A matched directory includes every file below it.
+	Inputs []string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// cacheEntry is a recorded task output, stored at <dir>/<key[:2]>/<key>.json.
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	Task      string    `json:"task"`
+	CreatedAt time.Time `json:"created_at"`
+	TaskState
+}
+
+// CacheKey returns the content-addressed cache key of a task.
+// It fails if an input file cannot be read.
+func CacheKey(t *Task) (string, error) {
+	h := sha256.New()
+	env := t.EnvMap
+	if len(env) == 0 {
+		env = nil
+	}
+	def, _ := json.Marshal(struct {
+		Command    string            `json:"command"`
+		Args       []string          `json:"args"`
+		EnvMap     map[string]string `json:"env_map"`
+		Image      string            `json:"image"`
+		WorkingDir string            `json:"working_dir"`
+		Backend    string            `json:"backend"`
+		HTTP       *HTTPRequest      `json:"http"`
+		Workflow   *SubWorkflow      `json:"workflow"`
+		Func       string            `json:"func,omitempty"`
+	}{t.Command, t.Args, env, t.Image, t.WorkingDir, t.Backend, t.HTTP, t.Workflow, funcKey(t)})
+	h.Write(def)
+	if t.Cache != nil {
+		globs := append([]string(nil), t.Cache.Inputs...)
+		sort.Strings(globs)
+		for _, glob := range globs {
+			files, err := cacheInputFiles(taskFilePath(t, glob))
+			if err != nil {
+				return "", fmt.Errorf("cache input %s: %w", glob, err)
+			}
+			fmt.Fprintf(h, "\x00glob %s %d", glob, len(files))
+			for _, f := range files {
+				sum, err := fileSHA256(f)
+				if err != nil {
+					return "", fmt.Errorf("cache input %s: %w", glob, err)
+				}
+				fmt.Fprintf(h, "\x00file %s %s", f, sum)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// funcKey identifies the handler of a task set with SetFunc by the task's name,
+// so that two such tasks never share cache entries. It is empty otherwise.
+func funcKey(t *Task) string {
+	if t.Func == nil {
+		return ""
+	}
+	return "task:" + t.Name
+}
+
+// cacheInputFiles returns the sorted files matched by glob, expanding directories.
+func cacheInputFiles(glob string) ([]string, error) {
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, m := range matches {
+		err := filepath.WalkDir(m, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's content.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheEntryPath returns the file of a cache entry.
+func cacheEntryPath(dir, key string) string {
+	return filepath.Join(dir, key[:2], key+".json")
+}
+
+// loadCacheEntry reads a cache entry, reporting false if there is none.
+func loadCacheEntry(dir, key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(cacheEntryPath(dir, key))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return nil, false
+	}
+	return &e, true
+}
+
+// saveCacheEntry writes a cache entry atomically.
+func saveCacheEntry(dir string, e *cacheEntry) error {
+	path := cacheEntryPath(dir, e.Key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), e.Key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runCached runs a task, consulting the workflow's cache if the task opted in.
+// A cache entry is only used if the task's assertions pass against the recorded
+// output; otherwise the task runs and, if it succeeds, its output is recorded.
+// The task runs with ctx. The returned status is empty for tasks that do not use the cache.
+func (w *Workflow) runCached(ctx context.Context, task *Task) (CacheStatus, error) {
+	if task.Cache == nil || w.CacheDir == "" {
+		return "", task.RunContext(ctx)
+	}
+	key, err := CacheKey(task)
+	if err != nil {
+		w.logger.Warn("Cannot compute cache key, running task", zap.String("task", task.Name), zap.Error(err))
+		return CacheMiss, task.RunContext(ctx)
+	}
+	if e, ok := loadCacheEntry(w.CacheDir, key); ok {
+		e.restore(task)
+		if err := RunAssertions(task); err == nil {
+			w.logger.Info("Restored task from cache", zap.String("task", task.Name), zap.String("key", key))
+			return CacheHit, nil
+		}
+		w.logger.Debug("Cached output fails assertions, running task", zap.String("task", task.Name))
+	}
+	if err := task.RunContext(ctx); err != nil {
+		return CacheMiss, err
+	}
+	e := &cacheEntry{
+		Key:       key,
+		Task:      task.Name,
+		CreatedAt: time.Now(),
+		TaskState: newTaskState(task, &TaskResult{Status: TaskStatusSucceeded, Duration: task.Actual.Duration}),
+	}
+	if err := saveCacheEntry(w.CacheDir, e); err != nil {
+		w.logger.Warn("Failed to save cache entry", zap.String("task", task.Name), zap.Error(err))
+	}
+	return CacheMiss, nil
+}
@@ -0,0 +1,167 @@
+package iapetus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("Dockerfile", "FROM alpine")
+	writeFile("src/main.go", "package main")
+	task := &Task{Name: "image", Command: "docker", Args: []string{"build", "."}, WorkingDir: dir}
+	task.EnableCache("Dockerfile", "src")
+
+	key := func() string {
+		t.Helper()
+		k, err := CacheKey(task)
+		if err != nil {
+			t.Fatalf("CacheKey failed: %v", err)
+		}
+		return k
+	}
+	base := key()
+
+	renamed := *task
+	renamed.Name = "other"
+	renamed.Retries = 3
+	if k, _ := CacheKey(&renamed); k != base {
+		t.Errorf("expected name and retries not to affect the key")
+	}
+
+	writeFile("src/main.go", "package main // changed")
+	if key() == base {
+		t.Errorf("expected input change to alter the key")
+	}
+	changed := key()
+	writeFile("src/util.go", "package main")
+	if key() == changed {
+		t.Errorf("expected new input file to alter the key")
+	}
+
+	task.Args = []string{"build", "--no-cache", "."}
+	if key() == changed {
+		t.Errorf("expected args change to alter the key")
+	}
+}
+
+func TestCacheKey_FuncTasks(t *testing.T) {
+	cacheDir := t.TempDir()
+	w := NewWorkflow("funcs", zap.NewNop())
+	w.CacheDir = cacheDir
+	for _, name := range []string{"first", "second"} {
+		out := name
+		w.AddTask(*NewTask(name, 0, zap.NewNop()).
+			SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+				return Output{Output: out}, nil
+			}).
+			EnableCache())
+	}
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, _ := CacheKey(&w.Steps[0])
+	second, _ := CacheKey(&w.Steps[1])
+	if first == second {
+		t.Fatalf("expected SetFunc tasks with the same definition to get different keys")
+	}
+	res := w.Result()
+	if res.Task("second").Cache != CacheMiss || w.Steps[1].Actual.Output != "second" {
+		t.Errorf("expected second task to run, got %+v with output %q", res.Task("second"), w.Steps[1].Actual.Output)
+	}
+}
+
+// cachedWorkflow builds a workflow with a cached step that records its runs.
+func cachedWorkflow(cacheDir, workDir string, runs *int) *Workflow {
+	w := NewWorkflow("cache", zap.NewNop())
+	w.CacheDir = cacheDir
+	task := NewTask("build", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			*runs++
+			return Output{Output: "built", Env: map[string]string{"IMAGE": "app:1"}}, nil
+		}).
+		EnableCache("input.txt").
+		AssertOutputContains("built")
+	task.WorkingDir = workDir
+	w.AddTask(*task)
+	w.AddTask(*NewTask("plain", 0, zap.NewNop()).AddCommand("true"))
+	return w
+}
+
+func TestWorkflow_Cache(t *testing.T) {
+	cacheDir, workDir := t.TempDir(), t.TempDir()
+	input := filepath.Join(workDir, "input.txt")
+	if err := os.WriteFile(input, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runs := 0
+
+	w := cachedWorkflow(cacheDir, workDir, &runs)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := w.Result().Task("build").Cache; got != CacheMiss || runs != 1 {
+		t.Fatalf("expected first run to miss, got %q after %d runs", got, runs)
+	}
+	if got := w.Result().Task("plain").Cache; got != "" {
+		t.Errorf("expected uncached task to have no cache status, got %q", got)
+	}
+
+	w = cachedWorkflow(cacheDir, workDir, &runs)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res := w.Result()
+	if res.Task("build").Cache != CacheHit || res.Task("build").Status != TaskStatusSucceeded || runs != 1 {
+		t.Fatalf("expected second run to hit, got %+v after %d runs", res.Task("build"), runs)
+	}
+	if out := w.Steps[0].Actual; out.Output != "built" || out.Env["IMAGE"] != "app:1" {
+		t.Errorf("expected recorded output to be restored, got %+v", out)
+	}
+	if res.CacheCount(CacheHit) != 1 || res.CacheCount(CacheMiss) != 0 {
+		t.Errorf("unexpected cache counts in %+v", res.Tasks)
+	}
+
+	if err := os.WriteFile(input, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w = cachedWorkflow(cacheDir, workDir, &runs)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := w.Result().Task("build").Cache; got != CacheMiss || runs != 2 {
+		t.Errorf("expected changed input to miss, got %q after %d runs", got, runs)
+	}
+
+	// A cached output that fails the current assertions is not used.
+	w = cachedWorkflow(cacheDir, workDir, &runs)
+	w.Steps[0].AddAssertion(AssertOutputContains("pushed"))
+	if err := w.Run(); err == nil {
+		t.Fatalf("expected assertion failure")
+	}
+	if runs != 3 {
+		t.Errorf("expected task to run when cached output fails assertions, got %d runs", runs)
+	}
+
+	// Without a cache dir the cache is disabled.
+	w = cachedWorkflow("", workDir, &runs)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := w.Result().Task("build").Cache; got != "" || runs != 4 {
+		t.Errorf("expected cache to be disabled, got %q after %d runs", got, runs)
+	}
+}
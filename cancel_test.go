@@ -0,0 +1,181 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// teardownWorkflow builds setup -> work -> teardown, where work runs fn and
+// teardown always runs and records that it did.
+func teardownWorkflow(fn TaskFunc, tornDown *bool) *Workflow {
+	ok := func(ctx context.Context, t *Task) (Output, error) { return Output{}, nil }
+	w := NewWorkflow("teardown", zap.NewNop())
+	w.AddTask(*NewTask("setup", 0, zap.NewNop()).SetFunc(ok))
+	work := NewTask("work", time.Minute, zap.NewNop()).SetFunc(fn)
+	work.Depends = []string{"setup"}
+	w.AddTask(*work)
+	report := NewTask("report", 0, zap.NewNop()).SetFunc(ok)
+	report.Depends = []string{"work"}
+	w.AddTask(*report)
+	teardown := NewTask("teardown", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			if ctx.Err() != nil {
+				return Output{}, ctx.Err()
+			}
+			*tornDown = true
+			return Output{}, nil
+		}).
+		SetAlwaysRun()
+	teardown.Depends = []string{"report"}
+	w.AddTask(*teardown)
+	return w
+}
+
+func TestWorkflow_AlwaysRunAfterFailure(t *testing.T) {
+	var tornDown bool
+	w := teardownWorkflow(func(ctx context.Context, t *Task) (Output, error) {
+		return Output{ExitCode: 1}, errors.New("boom")
+	}, &tornDown)
+
+	err := w.Run()
+	var wfErr *WorkflowError
+	if !errors.As(err, &wfErr) || wfErr.StepName != "work" {
+		t.Fatalf("expected work to fail the run, got %v", err)
+	}
+	assert.True(t, tornDown, "teardown must run after a failure")
+	res := w.Result()
+	assert.Equal(t, TaskStatusFailed, res.Task("work").Status)
+	assert.Equal(t, TaskStatusPending, res.Task("report").Status)
+	assert.Equal(t, TaskStatusSucceeded, res.Task("teardown").Status)
+}
+
+func TestWorkflow_CancelRunsTeardown(t *testing.T) {
+	var tornDown bool
+	w := teardownWorkflow(blockingFunc, &tornDown)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("received interrupt")
+	time.AfterFunc(20*time.Millisecond, func() { cancel(cause) })
+
+	err := w.RunContext(ctx)
+	var cancelled *WorkflowCancelledError
+	if !errors.As(err, &cancelled) {
+		t.Fatalf("expected WorkflowCancelledError, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected error to match the cancellation cause")
+	}
+	assert.Equal(t, "teardown", cancelled.WorkflowName)
+	assert.Equal(t, []string{"work", "report"}, cancelled.Unfinished)
+	assert.True(t, tornDown, "teardown must run after cancellation")
+
+	res := w.Result()
+	assert.Equal(t, TaskStatusSucceeded, res.Task("setup").Status)
+	assert.Equal(t, TaskStatusCancelled, res.Task("work").Status)
+	assert.Equal(t, TaskStatusCancelled, res.Task("report").Status)
+	assert.Equal(t, TaskStatusSucceeded, res.Task("teardown").Status)
+}
+
+func TestBashBackend_GracefulCancel(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	w := NewWorkflow("grace", zap.NewNop()).SetGracePeriod(5 * time.Second)
+	task := NewTask("trap", time.Minute, zap.NewNop())
+	task.Command = "sh"
+	// The backgrounded sleep keeps the output open unless the whole process
+	// group is signalled.
+	task.Args = []string{"-c", `trap "echo stopping; exit 3" TERM; sleep 30 & wait`}
+	task.parent = w
+	ctx, cancel := context.WithCancelCause(context.Background())
+	task.ctx = ctx
+	time.AfterFunc(200*time.Millisecond, func() { cancel(errors.New("received terminated")) })
+
+	start := time.Now()
+	err := (&BashBackend{}).RunTask(task)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("expected the command to stop within the grace period, took %v", elapsed)
+	}
+	if err == nil {
+		t.Fatalf("expected an interruption error")
+	}
+	assert.Equal(t, "stopping\n", task.Actual.Output)
+	assert.Equal(t, 3, task.Actual.ExitCode)
+}
+
+func TestPluginBackend_GracefulCancel(t *testing.T) {
+	dir := writeTestPlugin(t, "graceful")
+	marker := filepath.Join(t.TempDir(), "cleanup")
+	w := NewWorkflow("grace", zap.NewNop()).SetGracePeriod(5 * time.Second)
+	task := NewTask("plugin", time.Minute, zap.NewNop())
+	task.Command = "wait-cancel"
+	task.Args = []string{marker}
+	task.parent = w
+	ctx, cancel := context.WithCancelCause(context.Background())
+	task.ctx = ctx
+	// Give the plugin time to start serving before it is stopped.
+	time.AfterFunc(time.Second, func() { cancel(errors.New("received terminated")) })
+
+	start := time.Now()
+	err := NewPluginBackend("graceful", filepath.Join(dir, PluginPrefix+"graceful")).RunTask(task)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("expected the plugin to stop within the grace period, took %v", elapsed)
+	}
+	if err == nil {
+		t.Fatalf("expected an interruption error")
+	}
+	data, readErr := os.ReadFile(marker)
+	if readErr != nil {
+		t.Fatalf("expected the plugin to clean up before exiting: %v", readErr)
+	}
+	assert.Equal(t, "cleaned up", string(data))
+}
+
+func TestBashBackend_NoKillAfterExit(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var mu sync.Mutex
+	var sent []syscall.Signal
+	orig := sendSignal
+	sendSignal = func(cmd *exec.Cmd, group bool, sig syscall.Signal) error {
+		mu.Lock()
+		sent = append(sent, sig)
+		mu.Unlock()
+		return orig(cmd, group, sig)
+	}
+	defer func() { sendSignal = orig }()
+
+	w := NewWorkflow("grace", zap.NewNop()).SetGracePeriod(100 * time.Millisecond)
+	task := NewTask("quick", time.Minute, zap.NewNop())
+	task.Command = "sh"
+	task.Args = []string{"-c", `trap "exit 0" TERM; sleep 30 & wait`}
+	task.parent = w
+	ctx, cancel := context.WithCancelCause(context.Background())
+	task.ctx = ctx
+	time.AfterFunc(100*time.Millisecond, func() { cancel(errors.New("received terminated")) })
+	_ = (&BashBackend{}).RunTask(task)
+
+	// The command exited on SIGTERM; the kill scheduled after the grace period
+	// must not be sent.
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []syscall.Signal{syscall.SIGTERM}, sent)
+}
+
+func TestTask_GracePeriod(t *testing.T) {
+	task := NewTask("t", 0, zap.NewNop())
+	assert.Equal(t, DefaultGracePeriod, task.gracePeriod())
+	task.parent = NewWorkflow("w", zap.NewNop()).SetGracePeriod(time.Second)
+	assert.Equal(t, time.Second, task.gracePeriod())
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/yindia/iapetus"
 )
@@ -13,13 +22,240 @@ func printUsage() {
 
 Usage:
   iapetus run --config <workflow.yaml>
+  iapetus run --config <workflow.yaml> --resume <run-id>
+  iapetus plan --config <workflow.yaml> [--target t] [--from t] [--tags a,b] [--skip-tags c]
+  iapetus backends
+
+Backend plugins named iapetus-backend-<name> are discovered in --plugin-dir,
+$IAPETUS_PLUGIN_DIR and $PATH.
 
 Options:
-  --config   Path to workflow YAML config file (required)
-  --help     Show this help message
+  --config          Path to workflow YAML config file (required)
+  --skip-preflight  Skip backend availability and task validation checks
+  --plugin-dir      Extra directory to search for backend plugins
+  --update-golden   Rewrite golden files with the current output instead of comparing
+  --resume          Resume a failed run, skipping the steps that already succeeded
+  --state-dir       Directory where run state is saved (default .iapetus/runs)
+  --cache-dir       Directory where cached step results are stored (default .iapetus/cache)
+  --no-cache        Run every step even if a cached result exists
+  --timeout         Bound the whole run, e.g. 30m (overrides the workflow's timeout)
+  --grace-period    Time running steps get to stop after Ctrl-C or a timeout (default 10s)
+  --target          Run only these steps (comma-separated) and their dependencies
+  --from            Run only these steps (comma-separated) and their dependents
+  --tags            Run only steps with one of these tags (comma-separated)
+  --skip-tags       Skip steps with any of these tags (comma-separated)
+  --help            Show this help message
+
+On SIGINT or SIGTERM, iapetus run stops starting steps, gives running steps the
+grace period to exit, runs always_run steps, prints a summary and exits with
+130 (SIGINT) or 143 (SIGTERM). A second signal kills the running steps and exits.
 `)
 }
 
+// discoverPlugins registers external backend plugins, searching dir first if set.
+func discoverPlugins(dir string) {
+	if dir != "" {
+		iapetus.DiscoverPlugins(dir)
+		return
+	}
+	iapetus.DiscoverPlugins()
+}
+
+// printBackends writes the name and status of every registered backend.
+func printBackends(out io.Writer) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS")
+	for _, name := range iapetus.ListBackends() {
+		b := iapetus.GetBackend(name)
+		if b == nil {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", b.GetName(), b.GetStatus())
+	}
+	tw.Flush()
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// selectionFlags registers the task selection options on fs. The returned
+// function builds the Selection after fs is parsed.
+func selectionFlags(fs *flag.FlagSet) func() iapetus.Selection {
+	target := fs.String("target", "", "Run only these steps (comma-separated) and their dependencies")
+	from := fs.String("from", "", "Run only these steps (comma-separated) and their dependents")
+	tags := fs.String("tags", "", "Run only steps with one of these tags (comma-separated)")
+	skipTags := fs.String("skip-tags", "", "Skip steps with any of these tags (comma-separated)")
+	return func() iapetus.Selection {
+		return iapetus.Selection{
+			Targets:  splitList(*target),
+			From:     splitList(*from),
+			Tags:     splitList(*tags),
+			SkipTags: splitList(*skipTags),
+		}
+	}
+}
+
+// printPlan writes every step of a plan with whether it runs and why it was pruned.
+func printPlan(out io.Writer, plan *iapetus.Plan) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tACTION\tREASON")
+	for _, t := range plan.Tasks {
+		action := "run"
+		if t.Pruned {
+			action = "pruned"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, action, t.Reason)
+	}
+	tw.Flush()
+}
+
+// printPruned lists the steps a selection excludes from a run.
+func printPruned(out io.Writer, plan *iapetus.Plan) {
+	pruned := plan.Pruned()
+	fmt.Fprintf(out, "Running %d of %d steps\n", len(plan.Tasks)-len(pruned), len(plan.Tasks))
+	for _, t := range pruned {
+		fmt.Fprintf(out, "  pruned %s: %s\n", t.Name, t.Reason)
+	}
+}
+
+// printCache lists the cache status of every step that uses the cache.
+func printCache(out io.Writer, res *iapetus.RunResult) {
+	hits, misses := res.CacheCount(iapetus.CacheHit), res.CacheCount(iapetus.CacheMiss)
+	if hits+misses == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Cache: %d hit(s), %d miss(es)\n", hits, misses)
+	for _, t := range res.Tasks {
+		if t.Cache != "" {
+			fmt.Fprintf(out, "  %s %s\n", t.Cache, t.Name)
+		}
+	}
+}
+
+// printSummary writes the status and duration of every step, including the
+// steps of sub-workflows, e.g. after an interrupted run. Failed steps show why
+// they failed (see iapetus.Categorize).
+func printSummary(out io.Writer, res *iapetus.RunResult) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tSTATUS\tDURATION")
+	var write func(prefix string, r *iapetus.RunResult)
+	write = func(prefix string, r *iapetus.RunResult) {
+		for _, t := range r.Tasks {
+			duration := "-"
+			if t.Duration > 0 {
+				duration = t.Duration.Round(time.Millisecond).String()
+			}
+			status := string(t.Status)
+			if t.Status == iapetus.TaskStatusFailed {
+				status += " (" + string(t.Category()) + ")"
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\t%s\n", prefix, t.Name, status, duration)
+			if t.Workflow != nil {
+				write(prefix+t.Name+" > ", t.Workflow)
+			}
+		}
+	}
+	write("", res)
+	tw.Flush()
+}
+
+// signalExitCode is the conventional exit status of a process stopped by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// trapSignals returns a context that is cancelled on the first SIGINT or SIGTERM,
+// with the signal as its cause. A second signal kills the running steps, which
+// run in their own process groups and do not see the terminal's signals, and
+// exits the process.
+// The returned function stops trapping and reports the signal received, if any.
+func trapSignals(out io.Writer, grace time.Duration) (context.Context, func() os.Signal) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	var mu sync.Mutex
+	var received os.Signal
+	go func() {
+		sig, ok := <-sigCh
+		if !ok {
+			return
+		}
+		mu.Lock()
+		received = sig
+		mu.Unlock()
+		fmt.Fprintf(out, "\nReceived %v: stopping the workflow, running steps get %v to exit. Send it again to exit immediately.\n", sig, grace)
+		cancel(fmt.Errorf("received %v", sig))
+		if sig, ok = <-sigCh; ok {
+			fmt.Fprintf(out, "Received %v again: killing running steps and exiting\n", sig)
+			iapetus.KillRunningTasks()
+			os.Exit(signalExitCode(sig))
+		}
+	}()
+	return ctx, func() os.Signal {
+		signal.Stop(sigCh)
+		close(sigCh)
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is unset.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printFailure reports a workflow error, rendering assertion failures as diffs.
+// Failures inside sub-workflows name the path to the failing step.
+func printFailure(out io.Writer, err error, color bool) {
+	report := iapetus.RenderAssertionErrors(err, color)
+	var wfErr *iapetus.WorkflowError
+	isWfErr := errors.As(err, &wfErr)
+	if report == "" {
+		fmt.Fprintf(out, "Workflow failed: %v\n", err)
+		if isWfErr && len(wfErr.FailedSteps()) > 1 {
+			fmt.Fprintf(out, "Failed step: %s\n", strings.Join(wfErr.FailedSteps(), " > "))
+		}
+		return
+	}
+	if isWfErr {
+		fmt.Fprintf(out, "Workflow failed: assertions failed in step '%s' of workflow '%s'\n", strings.Join(wfErr.FailedSteps(), " > "), wfErr.WorkflowName)
+	} else {
+		fmt.Fprintln(out, "Workflow failed: assertions failed")
+	}
+	fmt.Fprint(out, report)
+}
+
+// printWarnings lists non-fatal assertion findings from a run, by task in step order.
+func printWarnings(out io.Writer, res *iapetus.RunResult, color bool) {
+	for _, t := range res.Tasks {
+		if len(t.Warnings) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "Warnings in step '%s':\n", t.Name)
+		errs := make(iapetus.AssertionErrors, 0, len(t.Warnings))
+		for _, w := range t.Warnings {
+			errs = append(errs, w)
+		}
+		fmt.Fprint(out, iapetus.RenderAssertionErrors(errs, color))
+	}
+}
+
 func main() {
 	if len(os.Args) < 2 || os.Args[1] == "--help" || os.Args[1] == "-h" {
 		printUsage()
@@ -30,6 +266,16 @@ func main() {
 	case "run":
 		runCmd := flag.NewFlagSet("run", flag.ExitOnError)
 		config := runCmd.String("config", "", "Path to workflow YAML config file (required)")
+		skipPreflight := runCmd.Bool("skip-preflight", false, "Skip backend availability and task validation checks")
+		pluginDir := runCmd.String("plugin-dir", "", "Extra directory to search for backend plugins")
+		updateGolden := runCmd.Bool("update-golden", false, "Rewrite golden files with the current output instead of comparing")
+		resume := runCmd.String("resume", "", "Resume a failed run, skipping the steps that already succeeded")
+		stateDir := runCmd.String("state-dir", iapetus.DefaultStateDir, "Directory where run state is saved")
+		cacheDir := runCmd.String("cache-dir", iapetus.DefaultCacheDir, "Directory where cached step results are stored")
+		noCache := runCmd.Bool("no-cache", false, "Run every step even if a cached result exists")
+		timeout := runCmd.Duration("timeout", 0, "Bound the whole run, e.g. 30m (overrides the workflow's timeout)")
+		gracePeriod := runCmd.Duration("grace-period", 0, "Time running steps get to stop after Ctrl-C or a timeout (default 10s)")
+		selection := selectionFlags(runCmd)
 		runCmd.Usage = printUsage
 
 		if err := runCmd.Parse(os.Args[2:]); err != nil {
@@ -41,15 +287,105 @@ func main() {
 			os.Exit(2)
 		}
 
+		discoverPlugins(*pluginDir)
+		iapetus.UpdateGolden = *updateGolden
 		wf, err := iapetus.LoadWorkflowFromYAML(*config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to load workflow: %v\n", err)
 			os.Exit(1)
 		}
-		if err := wf.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Workflow failed: %v\n", err)
+		if *skipPreflight {
+			wf.SkipPreflight = true
+		}
+		if *timeout > 0 {
+			wf.Timeout = *timeout
+		}
+		if *gracePeriod > 0 {
+			wf.GracePeriod = *gracePeriod
+		}
+		grace := wf.GracePeriod
+		if grace == 0 {
+			grace = iapetus.DefaultGracePeriod
+		}
+		wf.StateDir = *stateDir
+		if !*noCache {
+			wf.CacheDir = *cacheDir
+		}
+		wf.Selection = selection()
+		if !wf.Selection.IsEmpty() {
+			plan, err := wf.Plan()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid selection: %v\n", err)
+				os.Exit(2)
+			}
+			printPruned(os.Stderr, plan)
+		}
+		if *resume != "" {
+			state, err := iapetus.LoadRunState(*stateDir, *resume)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to resume: %v\n", err)
+				os.Exit(1)
+			}
+			wf.Resume(state)
+		}
+		ctx, stopSignals := trapSignals(os.Stderr, grace)
+		err = wf.RunContext(ctx)
+		sig := stopSignals()
+		res := wf.Result()
+		if res != nil {
+			printWarnings(os.Stderr, res, useColor(os.Stderr))
+			printCache(os.Stderr, res)
+		}
+		if err != nil {
+			var timeoutErr *iapetus.WorkflowTimeoutError
+			if res != nil && (sig != nil || errors.As(err, &timeoutErr)) {
+				printSummary(os.Stderr, res)
+			}
+			printFailure(os.Stderr, err, useColor(os.Stderr))
+			if res != nil && res.Count(iapetus.TaskStatusFailed)+res.Count(iapetus.TaskStatusTimedOut)+res.Count(iapetus.TaskStatusCancelled) > 0 {
+				fmt.Fprintf(os.Stderr, "Resume with: iapetus run --config %s --resume %s\n", *config, res.RunID)
+			}
+		}
+		if sig != nil {
+			os.Exit(signalExitCode(sig))
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+	case "plan":
+		planCmd := flag.NewFlagSet("plan", flag.ExitOnError)
+		config := planCmd.String("config", "", "Path to workflow YAML config file (required)")
+		selection := selectionFlags(planCmd)
+		planCmd.Usage = printUsage
+		if err := planCmd.Parse(os.Args[2:]); err != nil {
+			os.Exit(2)
+		}
+		if *config == "" {
+			fmt.Fprintln(os.Stderr, "Error: --config is required")
+			printUsage()
+			os.Exit(2)
+		}
+		wf, err := iapetus.LoadWorkflowFromYAML(*config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load workflow: %v\n", err)
 			os.Exit(1)
 		}
+		wf.Selection = selection()
+		plan, err := wf.Plan()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid selection: %v\n", err)
+			os.Exit(2)
+		}
+		printPlan(os.Stdout, plan)
+	case "backends":
+		backendsCmd := flag.NewFlagSet("backends", flag.ExitOnError)
+		pluginDir := backendsCmd.String("plugin-dir", "", "Extra directory to search for backend plugins")
+		backendsCmd.Usage = printUsage
+		if err := backendsCmd.Parse(os.Args[2:]); err != nil {
+			os.Exit(2)
+		}
+		discoverPlugins(*pluginDir)
+		printBackends(os.Stdout)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
 		printUsage()
@@ -0,0 +1,129 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/yindia/iapetus"
+	"go.uber.org/zap"
+)
+
+// TestSignalHelperProcess is not a real test: it runs a workflow under
+// trapSignals for TestTrapSignals_SecondSignalKillsSteps.
+func TestSignalHelperProcess(t *testing.T) {
+	pidFile := os.Getenv("IAPETUS_SIGNAL_HELPER")
+	if pidFile == "" {
+		return
+	}
+	// The step ignores SIGTERM, so only a kill stops it.
+	script := fmt.Sprintf(`trap "" TERM; sleep 60 & echo $! > %q; wait`, pidFile)
+	task := iapetus.NewTask("hang", time.Minute, zap.NewNop()).AddCommand("sh").AddArgs("-c", script)
+	wf := iapetus.NewWorkflow("signals", zap.NewNop()).SetGracePeriod(time.Minute).AddTask(*task)
+	ctx, stopSignals := trapSignals(os.Stderr, time.Minute)
+	_ = wf.RunContext(ctx)
+	stopSignals()
+	os.Exit(0)
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return cond()
+}
+
+// processGone reports whether pid has exited (a zombie counts as exited).
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return true
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestTrapSignals_SecondSignalKillsSteps(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSignalHelperProcess$")
+	cmd.Env = append(os.Environ(), "IAPETUS_SIGNAL_HELPER="+pidFile)
+	var stderr syncBuffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start helper: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	var pid int
+	if !waitFor(10*time.Second, func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}) {
+		t.Fatalf("step did not start; stderr: %s", stderr.String())
+	}
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatalf("failed to send first signal: %v", err)
+	}
+	if !waitFor(5*time.Second, func() bool { return strings.Contains(stderr.String(), "Send it again") }) {
+		t.Fatalf("first signal not handled; stderr: %s", stderr.String())
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatalf("failed to send second signal: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("helper did not exit after the second signal; stderr: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "killing running steps") {
+		t.Errorf("expected the second signal to be reported, got: %s", stderr.String())
+	}
+	if !waitFor(5*time.Second, func() bool { return processGone(pid) }) {
+		t.Errorf("step process %d is still running after exit", pid)
+	}
+}
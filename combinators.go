@@ -0,0 +1,162 @@
+package iapetus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Assertion kinds reported by the negative and composite assertions.
+const (
+	KindOutputNotContains = "output_not_contains"
+	KindExitCodeIn        = "exit_code_in"
+	KindNot               = "not"
+	KindAnyOf             = "any_of"
+)
+
+// AssertOutputNotContains returns an assertion that checks the output does not contain a substring
+func AssertOutputNotContains(substr string) func(*Task) error {
+	return func(i *Task) error {
+		if strings.Contains(i.Actual.Output, substr) {
+			return &AssertionError{
+				Kind:     KindOutputNotContains,
+				Message:  "output contains unexpected substring",
+				Expected: fmt.Sprintf("no %q", substr),
+				Actual:   quoteForDisplay(i.Actual.Output),
+			}
+		}
+		return nil
+	}
+}
+
+// AssertExitCodeIn returns an assertion that checks the exit code is one of codes
+func AssertExitCodeIn(codes ...int) func(*Task) error {
+	return func(i *Task) error {
+		for _, c := range codes {
+			if i.Actual.ExitCode == c {
+				return nil
+			}
+		}
+		return &AssertionError{
+			Kind:     KindExitCodeIn,
+			Message:  "exit code not in expected set",
+			Expected: fmt.Sprintf("one of %v", codes),
+			Actual:   fmt.Sprint(i.Actual.ExitCode),
+		}
+	}
+}
+
+// AssertExitCodeInRange returns an assertion that checks min <= exit code <= max
+func AssertExitCodeInRange(min, max int) func(*Task) error {
+	return func(i *Task) error {
+		if i.Actual.ExitCode < min || i.Actual.ExitCode > max {
+			return &AssertionError{
+				Kind:     KindExitCodeIn,
+				Message:  "exit code out of range",
+				Expected: fmt.Sprintf("%d..%d", min, max),
+				Actual:   fmt.Sprint(i.Actual.ExitCode),
+			}
+		}
+		return nil
+	}
+}
+
+// Not returns an assertion that passes only if assert fails.
+// Only failed checks (*AssertionError values) are inverted: any other error, such
+// as an unreadable file or an invalid expected value, is returned unchanged.
+func Not(assert func(*Task) error) func(*Task) error {
+	return func(i *Task) error {
+		err := assert(i)
+		if err == nil {
+			return &AssertionError{Kind: KindNot, Message: "negated assertion passed, expected it to fail"}
+		}
+		if !isCheckFailure(err) {
+			return err
+		}
+		return nil
+	}
+}
+
+// isCheckFailure reports whether err only reports failed checks (*AssertionError
+// values), as opposed to errors that kept an assertion from checking anything.
+func isCheckFailure(err error) bool {
+	if errs, ok := err.(AssertionErrors); ok {
+		for _, e := range errs {
+			if !isCheckFailure(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+	var ae *AssertionError
+	return errors.As(err, &ae)
+}
+
+// AnyOf returns an assertion that passes if at least one of asserts passes.
+// If all fail, the error lists every failure.
+func AnyOf(asserts ...func(*Task) error) func(*Task) error {
+	return func(i *Task) error {
+		var msgs []string
+		for _, assert := range asserts {
+			err := assert(i)
+			if err == nil {
+				return nil
+			}
+			msgs = append(msgs, err.Error())
+		}
+		return &AssertionError{
+			Kind:    KindAnyOf,
+			Message: fmt.Sprintf("none of %d assertions passed: %s", len(asserts), strings.Join(msgs, "; ")),
+		}
+	}
+}
+
+// Warn returns an assertion whose failures are downgraded to warnings: they are
+// recorded in Task.Actual.Warnings by RunAssertions but do not fail the task or
+// trigger retries.
+func Warn(assert func(*Task) error) func(*Task) error {
+	return func(i *Task) error {
+		err := assert(i)
+		if err == nil {
+			return nil
+		}
+		failures, ok := err.(AssertionErrors)
+		if !ok {
+			failures = AssertionErrors{err}
+		}
+		warnings := make(AssertionErrors, 0, len(failures))
+		for _, f := range failures {
+			w := &AssertionError{Message: f.Error()}
+			if ae, ok := f.(*AssertionError); ok {
+				copied := *ae
+				w = &copied
+			}
+			w.Severity = SeverityWarning
+			warnings = append(warnings, w)
+		}
+		if len(warnings) == 1 {
+			return warnings[0]
+		}
+		return warnings
+	}
+}
+
+// AllOf returns an assertion that passes only if every one of asserts passes.
+// All assertions run, and their failures are returned together as AssertionErrors.
+func AllOf(asserts ...func(*Task) error) func(*Task) error {
+	return func(i *Task) error {
+		var errs AssertionErrors
+		for _, assert := range asserts {
+			err := assert(i)
+			if nested, ok := err.(AssertionErrors); ok {
+				errs = append(errs, nested...)
+			} else if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package iapetus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNegativeAndExitCodeAssertions(t *testing.T) {
+	task := &Task{Actual: Output{ExitCode: 2, Output: "all good"}}
+	tests := []struct {
+		name    string
+		assert  func(*Task) error
+		wantErr bool
+	}{
+		{"not contains passes", AssertOutputNotContains("panic"), false},
+		{"not contains fails", AssertOutputNotContains("good"), true},
+		{"exit code in set", AssertExitCodeIn(0, 2), false},
+		{"exit code not in set", AssertExitCodeIn(0, 1), true},
+		{"exit code in range", AssertExitCodeInRange(1, 3), false},
+		{"exit code out of range", AssertExitCodeInRange(3, 5), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.assert(task)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	task := &Task{Actual: Output{ExitCode: 1, Output: "warning: deprecated"}}
+
+	if err := Not(AssertExitCode(0))(task); err != nil {
+		t.Errorf("Not: expected pass when inner fails, got %v", err)
+	}
+	if err := Not(AssertExitCode(1))(task); err == nil {
+		t.Errorf("Not: expected failure when inner passes")
+	}
+	broken := errors.New("invalid expected JSON")
+	if err := Not(func(*Task) error { return broken })(task); err != broken {
+		t.Errorf("Not: expected errors other than failed checks to pass through, got %v", err)
+	}
+	mixed := func(*Task) error { return AssertionErrors{&AssertionError{Message: "mismatch"}, broken} }
+	if err := Not(mixed)(task); err == nil {
+		t.Errorf("Not: expected failure when an inner error is not a failed check")
+	}
+
+	if err := AnyOf(AssertExitCode(0), AssertOutputContains("warning"))(task); err != nil {
+		t.Errorf("AnyOf: expected pass, got %v", err)
+	}
+	err := AnyOf(AssertExitCode(0), AssertOutputContains("ok"))(task)
+	if err == nil || !strings.Contains(err.Error(), "none of 2 assertions passed") || !strings.Contains(err.Error(), "exit code mismatch") {
+		t.Errorf("AnyOf: expected combined failure, got %v", err)
+	}
+
+	if err := AllOf(AssertExitCode(1), AssertOutputContains("warning"))(task); err != nil {
+		t.Errorf("AllOf: expected pass, got %v", err)
+	}
+	err = AllOf(AssertExitCode(0), AssertOutputContains("ok"), AssertOutputContains("warning"))(task)
+	if errs, ok := err.(AssertionErrors); !ok || len(errs) != 2 {
+		t.Errorf("AllOf: expected 2 aggregated failures, got %v", err)
+	}
+
+	nested := AnyOf(AllOf(AssertExitCode(1), Not(AssertOutputContains("error"))), AssertExitCode(0))
+	if err := nested(task); err != nil {
+		t.Errorf("nested combinators: expected pass, got %v", err)
+	}
+}
+
+func TestWarnAssertionsDoNotFail(t *testing.T) {
+	task := &Task{Actual: Output{ExitCode: 0, Output: "done in 12s"}}
+	task.Asserts = []func(*Task) error{
+		AssertExitCode(0),
+		Warn(AssertOutputContains("cached")),
+		Warn(AllOf(AssertOutputNotContains("12s"), AssertExitCode(1))),
+	}
+	if err := RunAssertions(task); err != nil {
+		t.Fatalf("expected warnings not to fail the task, got %v", err)
+	}
+	if len(task.Actual.Warnings) != 3 {
+		t.Fatalf("expected 3 warnings, got %v", task.Actual.Warnings)
+	}
+	for _, w := range task.Actual.Warnings {
+		if !w.IsWarning() || !strings.HasPrefix(w.Error(), "warning: ") {
+			t.Errorf("expected warning severity, got %q", w.Error())
+		}
+	}
+
+	task.Asserts = append(task.Asserts, AssertExitCode(2))
+	err := RunAssertions(task)
+	if errs, ok := err.(AssertionErrors); !ok || len(errs) != 1 {
+		t.Errorf("expected only the fatal failure, got %v", err)
+	}
+	if len(task.Actual.Warnings) != 3 {
+		t.Errorf("expected warnings to be reset per run, got %d", len(task.Actual.Warnings))
+	}
+}
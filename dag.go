@@ -155,6 +155,40 @@ func (d *DAG) GetTopologicalOrder() ([]*Task, error) {
 	return order, nil
 }
 
+// AddDependency makes an existing task depend on another existing task. It is
+// safe to call while the DAG is being read, e.g. by generators spawning tasks
+// during a run, and fails if the new edge would create a cycle.
+func (d *DAG) AddDependency(taskName, dep string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	node, exists := d.nodes[taskName]
+	if !exists {
+		return fmt.Errorf("task %s does not exist", taskName)
+	}
+	depNode, exists := d.nodes[dep]
+	if !exists {
+		return fmt.Errorf("dependency %s for task %s does not exist", dep, taskName)
+	}
+	if dep == taskName {
+		return fmt.Errorf("cycle detected involving task: %s", taskName)
+	}
+	ancestors := d.walk(dep, func(name string) []string {
+		if n, ok := d.nodes[name]; ok {
+			return n.Deps
+		}
+		return nil
+	})
+	for _, n := range ancestors {
+		if n == taskName {
+			return fmt.Errorf("cycle detected involving task: %s", taskName)
+		}
+	}
+	node.Deps = append(node.Deps, dep)
+	depNode.Depends = append(depNode.Depends, taskName)
+	d.edges[dep] = append(d.edges[dep], taskName)
+	return nil
+}
+
 // GetDependencies returns all dependencies for a task.
 func (d *DAG) GetDependencies(taskName string) ([]string, bool) {
 	d.mu.RLock()
@@ -176,3 +210,49 @@ func (d *DAG) GetDependents(taskName string) ([]string, bool) {
 	}
 	return append([]string{}, node.Depends...), true
 }
+
+// GetAllDependencies returns the transitive dependencies of a task, nearest first.
+func (d *DAG) GetAllDependencies(taskName string) ([]string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if _, exists := d.nodes[taskName]; !exists {
+		return nil, false
+	}
+	return d.walk(taskName, func(name string) []string {
+		if node, ok := d.nodes[name]; ok {
+			return node.Deps
+		}
+		return nil
+	}), true
+}
+
+// GetAllDependents returns the tasks that transitively depend on a task, nearest first.
+func (d *DAG) GetAllDependents(taskName string) ([]string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if _, exists := d.nodes[taskName]; !exists {
+		return nil, false
+	}
+	return d.walk(taskName, func(name string) []string { return d.edges[name] }), true
+}
+
+// walk returns the nodes reachable from start via next in breadth-first order,
+// excluding start. Callers must hold d.mu.
+func (d *DAG) walk(start string, next func(string) []string) []string {
+	seen := map[string]bool{start: true}
+	var out []string
+	queue := []string{start}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		for _, n := range next(curr) {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			out = append(out, n)
+			queue = append(queue, n)
+		}
+	}
+	return out
+}
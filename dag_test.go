@@ -3,6 +3,7 @@ package iapetus
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"testing"
 
@@ -404,3 +405,59 @@ func TestDAG_TopologicalOrder_Property(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestDAG_GetAllDependenciesAndDependents(t *testing.T) {
+	dag := NewDag()
+	// Dependents are added before their dependencies on purpose.
+	assert.NoError(t, dag.AddTask(&Task{Name: "deploy", Depends: []string{"build", "cluster"}}))
+	assert.NoError(t, dag.AddTask(&Task{Name: "build", Depends: []string{"fetch"}}))
+	assert.NoError(t, dag.AddTask(&Task{Name: "fetch"}))
+	assert.NoError(t, dag.AddTask(&Task{Name: "cluster"}))
+	assert.NoError(t, dag.AddTask(&Task{Name: "smoke", Depends: []string{"deploy"}}))
+
+	deps, ok := dag.GetAllDependencies("deploy")
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"build", "cluster", "fetch"}, deps)
+
+	dependents, ok := dag.GetAllDependents("fetch")
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []string{"build", "deploy", "smoke"}, dependents)
+
+	_, ok = dag.GetAllDependencies("notfound")
+	assert.False(t, ok)
+	_, ok = dag.GetAllDependents("notfound")
+	assert.False(t, ok)
+}
+
+func TestDAG_AddDependency(t *testing.T) {
+	dag := NewDag()
+	for _, task := range []*Task{
+		{Name: "gen"},
+		{Name: "a", Depends: []string{"gen"}},
+		{Name: "fan-in", Depends: []string{"gen"}},
+	} {
+		if err := dag.AddTask(task); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	if err := dag.AddDependency("fan-in", "a"); err != nil {
+		t.Fatalf("AddDependency failed: %v", err)
+	}
+	deps, _ := dag.GetDependencies("fan-in")
+	assert.Equal(t, []string{"gen", "a"}, deps)
+	dependents, _ := dag.GetAllDependents("a")
+	assert.Equal(t, []string{"fan-in"}, dependents)
+	if err := dag.Validate(); err != nil {
+		t.Errorf("expected valid DAG, got %v", err)
+	}
+
+	if err := dag.AddDependency("gen", "fan-in"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+	if err := dag.AddDependency("fan-in", "missing"); err == nil {
+		t.Errorf("expected error for missing dependency")
+	}
+	if err := dag.AddDependency("missing", "a"); err == nil {
+		t.Errorf("expected error for missing task")
+	}
+}
@@ -0,0 +1,62 @@
+package iapetus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("expected empty diff for equal input, got %q", got)
+	}
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := `--- golden
++++ output
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if got := UnifiedDiff("golden", "output", a, b); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	want = `--- a
++++ b
+@@ -0,0 +1 @@
++new
+`
+	if got := UnifiedDiff("a", "b", "", "new\n"); got != want {
+		t.Errorf("unexpected diff for empty input:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_TooLarge(t *testing.T) {
+	var a, b strings.Builder
+	a.WriteString("header\n")
+	b.WriteString("header\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&a, "expected %d\n", i)
+		fmt.Fprintf(&b, "actual %d\n", i)
+	}
+	b.WriteString("extra\n")
+	want := `--- golden
++++ output
+too many changes to diff: golden has 50001 lines, output has 50002 lines, first difference at line 2
+`
+	if got := UnifiedDiff("golden", "output", a.String(), b.String()); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -28,7 +28,14 @@
 //	workflow.Backend = "my-backend" // sets default for all tasks in the workflow
 //	task.SetBackend("my-backend")    // overrides for a specific task
 //
-// Built-in backends include "bash" (default) and "docker" (for containerized execution).
+// Backends can also be registered on a single workflow with Workflow.RegisterBackend;
+// these take precedence over the global registry. RegisterBackend returns an error if
+// the name is already taken (it no longer replaces the existing backend);
+// MustRegisterBackend panics instead. Use UnregisterBackend and ListBackends to
+// manage entries.
+//
+// Built-in backends include "bash" (default), "docker" (for containerized execution),
+// "kubernetes", and "func" (in-process Go handlers registered with RegisterTaskFunc).
 //
 // Example custom backend:
 //
@@ -37,7 +44,7 @@
 //	func (b *MyBackend) ValidateTask(task *iapetus.Task) error { return nil }
 //
 //	func init() {
-//	    iapetus.RegisterBackend("my-backend", &MyBackend{})
+//	    iapetus.MustRegisterBackend("my-backend", &MyBackend{})
 //	}
 //
 // Example usage:
@@ -55,5 +62,24 @@
 //	    log.Fatalf("Workflow failed: %v", err)
 //	}
 //
+// Assertion failures are returned as *AssertionError values (kind, expected, actual,
+// path and unified diff), each wrapped in an *AssertionFailure naming the failing
+// assertion and collected in AssertionErrors. Use errors.As or AssertionFailures
+// to inspect them and RenderAssertionErrors to print them:
+//
+//	fmt.Fprint(os.Stderr, iapetus.RenderAssertionErrors(err, true))
+//
+// Task errors are typed so callers can branch on them with errors.As, through a
+// WorkflowError too: *RetriesExhaustedError (every attempt failed, with the error
+// of each), *InterruptedError (the run stopped between attempts), *TimeoutError,
+// *BackendNotFoundError and *ValidationError.
+// Categorize maps an error to a FailureCategory for reports:
+//
+//	var retries *iapetus.RetriesExhaustedError
+//	if errors.As(err, &retries) {
+//	    log.Printf("%s failed %d times", retries.Task, len(retries.Attempts))
+//	}
+//	log.Printf("failure category: %s", iapetus.Categorize(err))
+//
 // See the README for full documentation and examples.
 package iapetus
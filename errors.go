@@ -0,0 +1,194 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a task attempt exceeds the task's own Timeout.
+// It matches context.DeadlineExceeded with errors.Is. Attempts interrupted by the
+// workflow's Timeout wrap the *WorkflowTimeoutError instead.
+type TimeoutError struct {
+	// Task is the name of the task that timed out.
+	Task string
+	// Timeout is the task's Timeout.
+	Timeout time.Duration
+}
+
+// Error implements the error interface for TimeoutError.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("task %s timed out after %v", e.Task, e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// BackendNotFoundError is returned when a task names a backend that is not registered.
+type BackendNotFoundError struct {
+	// Task is the name of the task.
+	Task string
+	// Backend is the backend name that could not be resolved.
+	Backend string
+}
+
+// Error implements the error interface for BackendNotFoundError.
+func (e *BackendNotFoundError) Error() string {
+	return fmt.Sprintf("backend %s not found", e.Backend)
+}
+
+// ValidationError is returned when a task is invalid before any attempt runs:
+// it has nothing to run, or its backend's ValidateTask rejected it.
+type ValidationError struct {
+	// Task is the name of the invalid task.
+	Task string
+	// Backend is the backend that rejected the task, if any.
+	Backend string
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements the error interface for ValidationError. It is the message of Err.
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying validation error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// AssertionFailure is one failure reported by one of a task's assertions.
+// RunAssertions returns them collected in AssertionErrors.
+type AssertionFailure struct {
+	// Task is the name of the task.
+	Task string
+	// Index is the position of the failing assertion in Task.Asserts. Assertions
+	// that report several failures (e.g. one per JSON path) share an index.
+	Index int
+	// Kind identifies the assertion (see AssertionError.Kind); empty if the
+	// assertion returned a plain error.
+	Kind string
+	// Err is the failure returned by the assertion, usually an *AssertionError.
+	Err error
+}
+
+// Error implements the error interface for AssertionFailure.
+func (e *AssertionFailure) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the failure returned by the assertion.
+func (e *AssertionFailure) Unwrap() error {
+	return e.Err
+}
+
+// RetriesExhaustedError is returned when every attempt of a task failed.
+type RetriesExhaustedError struct {
+	// Task is the name of the task.
+	Task string
+	// Attempts holds the error of every attempt, in order.
+	Attempts []error
+}
+
+// Error implements the error interface for RetriesExhaustedError.
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("task %s failed after %d attempts: %v", e.Task, len(e.Attempts), e.Last())
+}
+
+// Last returns the error of the last attempt.
+func (e *RetriesExhaustedError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1]
+}
+
+// Unwrap returns the errors of all attempts, so errors.Is and errors.As also
+// find a failure of an earlier attempt. Categorize and AssertionFailures only
+// look at the last attempt.
+func (e *RetriesExhaustedError) Unwrap() []error {
+	return e.Attempts
+}
+
+// InterruptedError is returned when a task's context is done between attempts:
+// the workflow timed out or was cancelled before the task used up its retries.
+// It matches the cancellation cause and every attempt's error with errors.Is.
+type InterruptedError struct {
+	// Task is the name of the task.
+	Task string
+	// Cause is the context's cancellation cause, e.g. a *WorkflowTimeoutError.
+	Cause error
+	// Retries is the number of attempts the task was allowed.
+	Retries int
+	// Attempts holds the error of every attempt that ran, in order.
+	Attempts []error
+}
+
+// Error implements the error interface for InterruptedError.
+func (e *InterruptedError) Error() string {
+	var last error
+	if len(e.Attempts) > 0 {
+		last = e.Attempts[len(e.Attempts)-1]
+	}
+	return fmt.Sprintf("task %s interrupted after %d of %d attempts: %v (last error: %v)", e.Task, len(e.Attempts), e.Retries, e.Cause, last)
+}
+
+// Unwrap returns the cancellation cause followed by the error of every attempt.
+func (e *InterruptedError) Unwrap() []error {
+	return append([]error{e.Cause}, e.Attempts...)
+}
+
+// FailureCategory classifies why a task or workflow failed (see Categorize).
+type FailureCategory string
+
+const (
+	// CategoryAssertion means an assertion failed (*AssertionFailure).
+	CategoryAssertion FailureCategory = "assertion"
+	// CategoryTimeout means the task exceeded its own Timeout (*TimeoutError).
+	CategoryTimeout FailureCategory = "timeout"
+	// CategoryInterrupted means the workflow timed out or was cancelled (e.g. *InterruptedError).
+	CategoryInterrupted FailureCategory = "interrupted"
+	// CategoryBackendNotFound means the task's backend is not registered (*BackendNotFoundError).
+	CategoryBackendNotFound FailureCategory = "backend_not_found"
+	// CategoryValidation means the task was invalid (*ValidationError).
+	CategoryValidation FailureCategory = "validation"
+	// CategoryError is any other failure, e.g. a command that could not start.
+	CategoryError FailureCategory = "error"
+)
+
+// Categorize returns the category of err, looking through wrapped errors such as
+// WorkflowError, or "" if err is nil. A task whose retries were exhausted is
+// categorized by its last attempt.
+func Categorize(err error) FailureCategory {
+	var (
+		retries     *RetriesExhaustedError
+		notFound    *BackendNotFoundError
+		invalid     *ValidationError
+		interrupted *InterruptedError
+		wfTimeout   *WorkflowTimeoutError
+		cancelled   *WorkflowCancelledError
+		timeout     *TimeoutError
+		assertion   *AssertionFailure
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &retries) && retries.Last() != nil:
+		return Categorize(retries.Last())
+	case errors.As(err, &notFound):
+		return CategoryBackendNotFound
+	case errors.As(err, &invalid):
+		return CategoryValidation
+	case errors.As(err, &interrupted), errors.As(err, &wfTimeout), errors.As(err, &cancelled), errors.Is(err, context.Canceled):
+		return CategoryInterrupted
+	case errors.As(err, &timeout):
+		return CategoryTimeout
+	case errors.As(err, &assertion):
+		return CategoryAssertion
+	}
+	return CategoryError
+}
@@ -0,0 +1,166 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestTaskErrors(t *testing.T) {
+	attempt := 0
+	flaky := NewTask("flaky", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			attempt++
+			return Output{}, fmt.Errorf("attempt %d failed", attempt)
+		}).
+		SetRetries(3).
+		SetRetryDelay(time.Millisecond)
+	err := flaky.Run()
+	var retries *RetriesExhaustedError
+	if !errors.As(err, &retries) {
+		t.Fatalf("expected RetriesExhaustedError, got %v", err)
+	}
+	assert.Equal(t, "flaky", retries.Task)
+	assert.Len(t, retries.Attempts, 3)
+	assert.EqualError(t, retries.Attempts[0], "task function failed: attempt 1 failed")
+	assert.EqualError(t, err, "task flaky failed after 3 attempts: task function failed: attempt 3 failed")
+	assert.Equal(t, CategoryError, Categorize(err))
+
+	slow := NewTask("slow", 10*time.Millisecond, zap.NewNop()).SetFunc(blockingFunc)
+	err = slow.Run()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+	assert.Equal(t, 10*time.Millisecond, timeout.Timeout)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, CategoryTimeout, Categorize(err))
+
+	invalid := &Task{Name: "invalid", Command: "echo", Backend: "docker"}
+	err = invalid.Run()
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	assert.Equal(t, "docker", validation.Backend)
+	assert.EqualError(t, err, "docker backend requires task.Image to be set")
+	assert.Equal(t, CategoryValidation, Categorize(err))
+
+	err = (&Task{Name: "empty"}).Run()
+	if !errors.As(err, &validation) || validation.Backend != "" {
+		t.Errorf("expected ValidationError without backend, got %v", err)
+	}
+}
+
+func TestTaskErrors_Attempts(t *testing.T) {
+	attempt := 0
+	task := NewTask("mixed", 20*time.Millisecond, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			attempt++
+			if attempt == 1 {
+				return blockingFunc(ctx, t)
+			}
+			return Output{Output: "nope"}, nil
+		}).
+		AssertOutputContains("ok").
+		SetRetries(2).
+		SetRetryDelay(time.Millisecond)
+	err := task.Run()
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Errorf("expected the first attempt's TimeoutError to be found, got %v", err)
+	}
+	assert.Equal(t, CategoryAssertion, Categorize(err), "categorized by the last attempt")
+	assert.Len(t, AssertionFailures(err), 1)
+
+	cause := errors.New("received interrupt")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	flaky := NewTask("flaky", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			cancel(cause)
+			return Output{}, errors.New("not yet")
+		}).
+		SetRetries(5).
+		SetRetryDelay(time.Minute)
+	err = flaky.RunContext(ctx)
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) {
+		t.Fatalf("expected InterruptedError, got %v", err)
+	}
+	assert.Equal(t, 5, interrupted.Retries)
+	assert.Len(t, interrupted.Attempts, 1)
+	assert.True(t, errors.Is(err, cause))
+	assert.True(t, errors.Is(err, interrupted.Attempts[0]))
+	assert.Equal(t, CategoryInterrupted, Categorize(err))
+	assert.Contains(t, err.Error(), "interrupted after 1 of 5 attempts")
+}
+
+func TestTaskErrors_ThroughWorkflowError(t *testing.T) {
+	w := NewWorkflow("wf", zap.NewNop()).SetSkipPreflight(true)
+	w.AddTask(Task{Name: "lost", Command: "echo", Backend: "nope"})
+	err := w.Run()
+	var wfErr *WorkflowError
+	var notFound *BackendNotFoundError
+	if !errors.As(err, &wfErr) || !errors.As(err, &notFound) {
+		t.Fatalf("expected WorkflowError wrapping BackendNotFoundError, got %v", err)
+	}
+	assert.Equal(t, "lost", notFound.Task)
+	assert.Equal(t, "nope", notFound.Backend)
+	assert.Equal(t, CategoryBackendNotFound, Categorize(err))
+	assert.Equal(t, CategoryBackendNotFound, w.Result().Task("lost").Category())
+}
+
+func TestRunAssertions_AssertionFailure(t *testing.T) {
+	task := NewTask("check", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			return Output{Output: `{"a": 1, "b": 2}`}, nil
+		}).
+		AddAssertion(AssertExitCode(0)).
+		AddAssertion(AssertOutputJsonEquals(`{"a": 2, "b": 3}`)).
+		AddAssertion(func(t *Task) error { return errors.New("custom") })
+	err := task.Run()
+
+	var failure *AssertionFailure
+	if !errors.As(err, &failure) {
+		t.Fatalf("expected AssertionFailure, got %v", err)
+	}
+	assert.Equal(t, "check", failure.Task)
+	assert.Equal(t, 1, failure.Index)
+	assert.Equal(t, KindJSONEquals, failure.Kind)
+	assert.Equal(t, CategoryAssertion, Categorize(err))
+
+	var errs AssertionErrors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expected 3 failures, got %v", err)
+	}
+	last := errs[2].(*AssertionFailure)
+	assert.Equal(t, 2, last.Index)
+	assert.Equal(t, "", last.Kind)
+	assert.EqualError(t, last, "custom")
+	assert.Len(t, AssertionFailures(err), 2, "plain errors are not AssertionErrors")
+}
+
+func TestCategorize(t *testing.T) {
+	cancelled := &WorkflowCancelledError{WorkflowName: "w", Cause: context.Canceled}
+	tests := []struct {
+		err  error
+		want FailureCategory
+	}{
+		{nil, ""},
+		{errors.New("exit status 1"), CategoryError},
+		{&WorkflowError{StepName: "s", Err: &TimeoutError{Task: "s"}}, CategoryTimeout},
+		{&WorkflowTimeoutError{WorkflowName: "w"}, CategoryInterrupted},
+		{cancelled, CategoryInterrupted},
+		{&RetriesExhaustedError{Task: "s", Attempts: []error{&ValidationError{Err: errors.New("bad")}}}, CategoryValidation},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Categorize(tt.err), "%v", tt.err)
+	}
+	assert.Equal(t, CategoryInterrupted, TaskResult{Status: TaskStatusCancelled, Err: errors.New("received interrupt")}.Category())
+	assert.Equal(t, FailureCategory(""), TaskResult{Status: TaskStatusSucceeded}.Category())
+}
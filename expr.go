@@ -0,0 +1,109 @@
+package iapetus
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// KindExpr is the AssertionError kind reported by expression assertions.
+const KindExpr = "expr"
+
+var (
+	exprEnvOnce sync.Once
+	exprEnv     *cel.Env
+	exprEnvErr  error
+)
+
+// exprEnvironment returns the shared CEL environment declaring the task variables.
+func exprEnvironment() (*cel.Env, error) {
+	exprEnvOnce.Do(func() {
+		exprEnv, exprEnvErr = cel.NewEnv(
+			cel.Variable("output", cel.StringType),
+			cel.Variable("json", cel.DynType),
+			cel.Variable("exit_code", cel.IntType),
+			cel.Variable("status_code", cel.IntType),
+			cel.Variable("duration", cel.DurationType),
+			cel.Variable("env", cel.MapType(cel.StringType, cel.StringType)),
+		)
+	})
+	return exprEnv, exprEnvErr
+}
+
+// AssertExpr compiles a CEL expression into an assertion that passes when it evaluates to true.
+// Compilation errors (syntax, unknown variables, non-boolean result) are returned immediately.
+//
+// Available variables:
+//   - output: the normalized output (string)
+//   - json: the output parsed as JSON, or null if it is not JSON
+//   - exit_code, status_code: ints
+//   - duration: execution time of the task
+//   - env: the task's EnvMap merged with variables exported via $IAPETUS_ENV
+//
+// Example: json.items.all(p, p.status.phase == "Running") && exit_code == 0
+func AssertExpr(expression string) (func(*Task) error, error) {
+	env, err := exprEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(expression)
+	if iss != nil && iss.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, iss.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("invalid expression %q: must evaluate to bool, got %s", expression, t)
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
+	}
+	return func(i *Task) error {
+		out, _, err := prg.Eval(exprActivation(i))
+		if err != nil {
+			return fmt.Errorf("expression %q failed: %w", expression, err)
+		}
+		ok, isBool := out.Value().(bool)
+		if !isBool {
+			return fmt.Errorf("expression %q returned %v, expected bool", expression, out.Value())
+		}
+		if !ok {
+			return &AssertionError{Kind: KindExpr, Message: fmt.Sprintf("expression %q evaluated to false", expression)}
+		}
+		return nil
+	}, nil
+}
+
+// MustAssertExpr is like AssertExpr but panics if the expression does not compile.
+func MustAssertExpr(expression string) func(*Task) error {
+	assert, err := AssertExpr(expression)
+	if err != nil {
+		panic(err)
+	}
+	return assert
+}
+
+// exprActivation builds the variables for evaluating an expression against a task.
+func exprActivation(t *Task) map[string]interface{} {
+	output := normalizeOutput(t.Actual.Output)
+	var doc interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		doc = nil
+	}
+	env := make(map[string]string, len(t.EnvMap)+len(t.Actual.Env))
+	for k, v := range t.EnvMap {
+		env[k] = v
+	}
+	for k, v := range t.Actual.Env {
+		env[k] = v
+	}
+	return map[string]interface{}{
+		"output":      output,
+		"json":        doc,
+		"exit_code":   t.Actual.ExitCode,
+		"status_code": t.Actual.StatusCode,
+		"duration":    t.Actual.Duration,
+		"env":         env,
+	}
+}
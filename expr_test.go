@@ -0,0 +1,63 @@
+package iapetus
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssertExpr(t *testing.T) {
+	task := &Task{
+		EnvMap: map[string]string{"STAGE": "dev"},
+		Actual: Output{
+			ExitCode: 0,
+			Output:   `{"items":[{"status":{"phase":"Running"}},{"status":{"phase":"Running"}}]}`,
+			Duration: 2 * time.Second,
+			Env:      map[string]string{"VERSION": "1.2.3"},
+		},
+	}
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{`json.items.all(p, p.status.phase == "Running")`, false},
+		{`size(json.items) == 2 && exit_code == 0`, false},
+		{`output.contains("Running")`, false},
+		{`env.STAGE == "dev" && env["VERSION"] == "1.2.3"`, false},
+		{`duration < duration("5s")`, false},
+		{`json.items.exists(p, p.status.phase == "Pending")`, true},
+		{`json.missing.field == 1`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			assert, err := AssertExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			if err := assert(task); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	assert, _ := AssertExpr(`exit_code == 1`)
+	err := assert(task)
+	if failures := AssertionFailures(err); len(failures) != 1 || failures[0].Kind != KindExpr {
+		t.Errorf("expected an expr AssertionError, got %v", err)
+	}
+}
+
+func TestAssertExpr_CompileErrors(t *testing.T) {
+	for _, expr := range []string{`exit_code ==`, `unknown_var == 1`, `output + "x"`, `exit_code + 1`} {
+		if _, err := AssertExpr(expr); err == nil || !strings.Contains(err.Error(), "invalid expression") {
+			t.Errorf("%q: expected compile error, got %v", expr, err)
+		}
+	}
+}
+
+func TestAssertExpr_NonJSONOutput(t *testing.T) {
+	task := &Task{Actual: Output{Output: "plain text"}}
+	if err := MustAssertExpr(`json == null && output == "plain text"`)(task); err != nil {
+		t.Errorf("expected json to be null for non-JSON output, got %v", err)
+	}
+}
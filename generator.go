@@ -0,0 +1,159 @@
+package iapetus
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Generator makes a task spawn new tasks into the running workflow (see Task.Generate).
+//
+// When the generating task succeeds, its output is parsed as a JSON list and one
+// task is created from Template per item. Generated tasks depend on the generator
+// (and on Template.Depends), and every task in FanIn waits for all of them.
+type Generator struct {
+	// Template is copied for every item. "{{item}}" and "{{index}}" in its Name,
+	// Command, Args and EnvMap values are replaced by the item (strings as-is,
+	// other values as JSON) and its position; the item is also set as $IAPETUS_ITEM.
+	// If Name is empty, generated tasks are named "<generator>-<index>"; otherwise
+	// it must contain "{{item}}" or "{{index}}" so every generated name is unique.
+	Template *Task `json:"-" yaml:"-"`
+	// FanIn names tasks that wait for every generated task. Each must depend on the generator.
+	FanIn []string `json:"fan_in,omitempty" yaml:"fan_in,omitempty"`
+}
+
+// SetGenerate makes the task spawn a task from template for every item of its JSON
+// list output, with fanIn waiting for all of them.
+func (t *Task) SetGenerate(template *Task, fanIn ...string) *Task {
+	t.Generate = &Generator{Template: template, FanIn: fanIn}
+	return t
+}
+
+// validateGenerators checks that every generator has a template whose name is
+// unique per item, and that its fan-in tasks exist and depend on it.
+func validateGenerators(steps []Task) error {
+	byName := make(map[string]*Task, len(steps))
+	for i := range steps {
+		byName[steps[i].Name] = &steps[i]
+	}
+	for i := range steps {
+		g := steps[i].Generate
+		if g == nil {
+			continue
+		}
+		if g.Template == nil {
+			return fmt.Errorf("generator %s has no template", steps[i].Name)
+		}
+		if name := g.Template.Name; name != "" && !strings.Contains(name, "{{item}}") && !strings.Contains(name, "{{index}}") {
+			return fmt.Errorf("generator %s: template name %q must contain {{item}} or {{index}}", steps[i].Name, name)
+		}
+		for _, name := range g.FanIn {
+			fanIn, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("fan-in task %s of generator %s does not exist", name, steps[i].Name)
+			}
+			if !dependsOn(fanIn, steps[i].Name) {
+				return fmt.Errorf("fan-in task %s must depend on generator %s", name, steps[i].Name)
+			}
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether t lists dep as a direct dependency.
+func dependsOn(t *Task, dep string) bool {
+	for _, d := range t.Depends {
+		if d == dep {
+			return true
+		}
+	}
+	return false
+}
+
+// generatorItems parses a generator's output as a JSON list and returns every
+// item as a string: strings as-is, other values as compact JSON.
+func generatorItems(output string) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &raw); err != nil {
+		return nil, fmt.Errorf("output is not a JSON list: %w", err)
+	}
+	items := make([]string, len(raw))
+	for i, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err == nil {
+			items[i] = s
+			continue
+		}
+		var v interface{}
+		_ = json.Unmarshal(r, &v)
+		b, _ := json.Marshal(v)
+		items[i] = string(b)
+	}
+	return items, nil
+}
+
+// generate builds the tasks spawned by a generator that finished successfully.
+func (w *Workflow) generate(gen *Task) ([]*Task, error) {
+	items, err := generatorItems(gen.Actual.Output)
+	if err != nil {
+		return nil, fmt.Errorf("generator %s: %w", gen.Name, err)
+	}
+	tmpl := gen.Generate.Template
+	tasks := make([]*Task, 0, len(items))
+	for i, item := range items {
+		r := strings.NewReplacer("{{item}}", item, "{{index}}", strconv.Itoa(i))
+		t := copyTemplate(tmpl)
+		t.Name = r.Replace(tmpl.Name)
+		if tmpl.Name == "" {
+			t.Name = fmt.Sprintf("%s-%d", gen.Name, i)
+		}
+		t.Command = r.Replace(tmpl.Command)
+		t.Args = make([]string, len(tmpl.Args))
+		for j, a := range tmpl.Args {
+			t.Args[j] = r.Replace(a)
+		}
+		env := tmpl.EnvMap
+		if len(env) == 0 {
+			env = w.EnvMap
+		}
+		t.EnvMap = make(map[string]string, len(env)+1)
+		for k, v := range env {
+			t.EnvMap[k] = r.Replace(v)
+		}
+		t.EnvMap["IAPETUS_ITEM"] = item
+		t.Depends = append([]string{gen.Name}, tmpl.Depends...)
+		t.generatedBy = gen.Name
+		w.prepareTask(&t)
+		tasks = append(tasks, &t)
+	}
+	return tasks, nil
+}
+
+// copyTemplate returns a copy of a generator template that shares no slices,
+// maps or request definitions with it, so generated tasks can be changed
+// independently of each other.
+func copyTemplate(tmpl *Task) Task {
+	t := *tmpl
+	t.Actual = Output{}
+	t.Asserts = append([]func(*Task) error(nil), tmpl.Asserts...)
+	t.Tags = append([]string(nil), tmpl.Tags...)
+	if tmpl.Cache != nil {
+		cache := *tmpl.Cache
+		cache.Inputs = append([]string(nil), tmpl.Cache.Inputs...)
+		t.Cache = &cache
+	}
+	if tmpl.HTTP != nil {
+		req := *tmpl.HTTP
+		req.Headers = maps.Clone(tmpl.HTTP.Headers)
+		t.HTTP = &req
+	}
+	if tmpl.Workflow != nil {
+		sw := *tmpl.Workflow
+		sw.Params = maps.Clone(tmpl.Workflow.Params)
+		sw.Outputs = maps.Clone(tmpl.Workflow.Outputs)
+		t.Workflow = &sw
+	}
+	return t
+}
@@ -0,0 +1,164 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// generatorWorkflow builds discover -> (one task per suite) -> report, where
+// discover prints output and every task records its name on completion.
+func generatorWorkflow(output string, tmpl *Task) (*Workflow, *[]string) {
+	var mu sync.Mutex
+	var ran []string
+	record := func(ctx context.Context, t *Task) (Output, error) {
+		mu.Lock()
+		ran = append(ran, t.Name)
+		mu.Unlock()
+		return Output{Output: t.EnvMap["IAPETUS_ITEM"]}, nil
+	}
+	if tmpl == nil {
+		tmpl = NewTask("test-{{item}}", 0, zap.NewNop())
+	}
+	tmpl.SetFunc(record)
+
+	w := NewWorkflow("gen", zap.NewNop())
+	w.AddTask(*NewTask("discover", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			return Output{Output: output}, nil
+		}).
+		SetGenerate(tmpl, "report"))
+	report := NewTask("report", 0, zap.NewNop()).SetFunc(record)
+	report.Depends = []string{"discover"}
+	w.AddTask(*report)
+	return w, &ran
+}
+
+func TestGenerator_SpawnsTasksAndFansIn(t *testing.T) {
+	w, ran := generatorWorkflow(`["api", "web", "cli"]`, nil)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.ElementsMatch(t, []string{"test-api", "test-web", "test-cli", "report"}, *ran)
+	assert.Equal(t, "report", (*ran)[len(*ran)-1], "fan-in must run after every generated task")
+
+	res := w.Result()
+	var names []string
+	for _, r := range res.Tasks {
+		names = append(names, r.Name)
+		assert.Equal(t, TaskStatusSucceeded, r.Status, r.Name)
+	}
+	assert.Equal(t, []string{"discover", "test-api", "test-web", "test-cli", "report"}, names)
+	assert.Equal(t, "discover", res.Task("test-web").GeneratedBy)
+	assert.Equal(t, "", res.Task("report").GeneratedBy)
+}
+
+func TestGenerator_TemplateExpansion(t *testing.T) {
+	tmpl := &Task{}
+	tmpl.Command = "run-{{index}}"
+	tmpl.Args = []string{"--suite={{item}}"}
+	tmpl.EnvMap = map[string]string{"SUITE": "{{item}}"}
+	w, _ := generatorWorkflow(`[{"name": "api"}, 7]`, tmpl)
+	w.Steps[0].Generate.FanIn = nil
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := w.generate(&w.Steps[0])
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	first := tasks[0]
+	assert.Equal(t, "discover-0", first.Name)
+	assert.Equal(t, "run-0", first.Command)
+	assert.Equal(t, []string{`--suite={"name":"api"}`}, first.Args)
+	assert.Equal(t, map[string]string{"SUITE": `{"name":"api"}`, "IAPETUS_ITEM": `{"name":"api"}`}, first.EnvMap)
+	assert.Equal(t, []string{"discover"}, first.Depends)
+	assert.Equal(t, "7", tasks[1].EnvMap["IAPETUS_ITEM"])
+	assert.Equal(t, TaskStatusSucceeded, w.Result().Task("discover-1").Status)
+}
+
+func TestGenerator_TasksDoNotShareTemplate(t *testing.T) {
+	tmpl := NewTask("test-{{item}}", 0, zap.NewNop()).
+		SetHTTP(&HTTPRequest{URL: "http://localhost", Headers: map[string]string{"X-Suite": "all"}})
+	tmpl.Asserts = make([]func(*Task) error, 0, 4)
+	tmpl.Tags = []string{"generated"}
+	w, _ := generatorWorkflow(`["api", "web"]`, tmpl)
+	w.Steps[0].Actual.Output = `["api", "web"]`
+	tasks, err := w.generate(&w.Steps[0])
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	first, second := tasks[0], tasks[1]
+	first.AssertOutputEquals("api")
+	second.AssertOutputEquals("web")
+	first.Tags[0] = "changed"
+	first.HTTP.Headers["X-Suite"] = "api"
+	first.Actual.Output = "api"
+	assert.NoError(t, RunAssertions(first))
+	assert.Len(t, tmpl.Asserts, 0)
+	assert.Equal(t, []string{"generated"}, second.Tags)
+	assert.Equal(t, []string{"generated"}, tmpl.Tags)
+	assert.Equal(t, "all", second.HTTP.Headers["X-Suite"])
+	assert.Equal(t, "all", tmpl.HTTP.Headers["X-Suite"])
+}
+
+func TestGenerator_EmptyList(t *testing.T) {
+	w, ran := generatorWorkflow(`[]`, nil)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, []string{"report"}, *ran)
+}
+
+func TestGenerator_MaxParallel(t *testing.T) {
+	w, ran := generatorWorkflow(`["a", "b", "c", "d", "e"]`, nil)
+	w.SetMaxParallel(1)
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, []string{"test-a", "test-b", "test-c", "test-d", "test-e", "report"}, *ran)
+}
+
+func TestGenerator_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		setup  func(w *Workflow)
+		step   string
+		want   string
+	}{
+		{"not a list", `{"suites": []}`, nil, "discover", "not a JSON list"},
+		{"name clash", `["x", "x"]`, nil, "discover", "already exists"},
+		{"clash with step", `["report"]`, func(w *Workflow) { w.Steps[0].Generate.Template.Name = "{{item}}" }, "discover", "already exists"},
+		{"fan-in cycle", `["x"]`, func(w *Workflow) { w.Steps[0].Generate.Template.Depends = []string{"report"} }, "discover", "cycle"},
+		{"no template", `[]`, func(w *Workflow) { w.Steps[0].Generate.Template = nil }, "DAG", "has no template"},
+		{"static name", `["x"]`, func(w *Workflow) { w.Steps[0].Generate.Template.Name = "test" }, "DAG", "must contain {{item}} or {{index}}"},
+		{"unknown fan-in", `[]`, func(w *Workflow) { w.Steps[0].Generate.FanIn = []string{"nope"} }, "DAG", "does not exist"},
+		{"fan-in not dependent", `[]`, func(w *Workflow) { w.Steps[1].Depends = nil }, "DAG", "must depend on generator"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := generatorWorkflow(tt.output, nil)
+			if tt.setup != nil {
+				tt.setup(w)
+			}
+			err := w.Run()
+			var wfErr *WorkflowError
+			if !errors.As(err, &wfErr) {
+				t.Fatalf("expected WorkflowError, got %v", err)
+			}
+			assert.Equal(t, tt.step, wfErr.StepName)
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
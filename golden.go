@@ -0,0 +1,146 @@
+package iapetus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// UpdateGoldenEnv is the environment variable that, when set to a non-empty value
+// other than "0" or "false", makes golden assertions rewrite their files.
+const UpdateGoldenEnv = "IAPETUS_UPDATE_GOLDEN"
+
+// UpdateGolden makes AssertOutputMatchesGolden write the normalized output to the
+// golden file instead of comparing against it. `iapetus run --update-golden` sets it.
+var UpdateGolden bool
+
+// updateGolden reports whether golden files should be rewritten.
+func updateGolden() bool {
+	if UpdateGolden {
+		return true
+	}
+	v := strings.ToLower(os.Getenv(UpdateGoldenEnv))
+	return v != "" && v != "0" && v != "false"
+}
+
+// Normalizer rewrites volatile parts of the output before a golden comparison.
+type Normalizer func(string) string
+
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	uuidPattern      = regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	ansiPattern      = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+)
+
+// NormalizeTimestamps replaces RFC 3339 style timestamps with <TIMESTAMP>.
+func NormalizeTimestamps(s string) string {
+	return timestampPattern.ReplaceAllString(s, "<TIMESTAMP>")
+}
+
+// NormalizeUUIDs replaces UUIDs with <UUID>.
+func NormalizeUUIDs(s string) string {
+	return uuidPattern.ReplaceAllString(s, "<UUID>")
+}
+
+// NormalizeANSI strips ANSI escape sequences (colors, cursor movement).
+func NormalizeANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// NormalizeRegexp returns a Normalizer that replaces every match of pattern with repl.
+func NormalizeRegexp(pattern, repl string) (Normalizer, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid normalizer pattern %q: %w", pattern, err)
+	}
+	return func(s string) string { return re.ReplaceAllString(s, repl) }, nil
+}
+
+var (
+	normalizersMu sync.RWMutex
+	normalizers   = map[string]Normalizer{
+		"timestamps": NormalizeTimestamps,
+		"uuids":      NormalizeUUIDs,
+		"ansi":       NormalizeANSI,
+	}
+)
+
+// RegisterNormalizer makes a Normalizer available by name to `golden_normalizers` in YAML.
+func RegisterNormalizer(name string, n Normalizer) error {
+	if name == "" {
+		return fmt.Errorf("normalizer name must not be empty")
+	}
+	if n == nil {
+		return fmt.Errorf("normalizer %s is nil", name)
+	}
+	normalizersMu.Lock()
+	defer normalizersMu.Unlock()
+	if _, exists := normalizers[name]; exists {
+		return fmt.Errorf("normalizer %s already registered", name)
+	}
+	normalizers[name] = n
+	return nil
+}
+
+// GetNormalizer retrieves a Normalizer by name, or nil if not found.
+func GetNormalizer(name string) Normalizer {
+	normalizersMu.RLock()
+	defer normalizersMu.RUnlock()
+	return normalizers[name]
+}
+
+// listNormalizers returns the registered normalizer names in sorted order.
+func listNormalizers() []string {
+	normalizersMu.RLock()
+	defer normalizersMu.RUnlock()
+	names := make([]string, 0, len(normalizers))
+	for name := range normalizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AssertOutputMatchesGolden returns an assertion that compares the output, after
+// line-ending normalization and the given normalizers, to the contents of a golden file.
+// When UpdateGolden (or $IAPETUS_UPDATE_GOLDEN) is set, the file is (re)written instead.
+func AssertOutputMatchesGolden(path string, normalizers ...Normalizer) func(*Task) error {
+	return func(i *Task) error {
+		actual := normalizeOutput(i.Actual.Output)
+		for _, n := range normalizers {
+			actual = n(actual)
+		}
+		if updateGolden() {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return fmt.Errorf("failed to update golden file %s: %w", path, err)
+			}
+			if err := os.WriteFile(path, []byte(actual+"\n"), 0o644); err != nil {
+				return fmt.Errorf("failed to update golden file %s: %w", path, err)
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("golden file %s does not exist (run with --update-golden to create it)", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read golden file %s: %w", path, err)
+		}
+		expected := normalizeOutput(string(data))
+		if actual != expected {
+			return &AssertionError{
+				Kind:     KindGolden,
+				Message:  "output does not match golden file " + path,
+				Expected: expected,
+				Actual:   actual,
+				Diff:     UnifiedDiff(path, "output", expected+"\n", actual+"\n"),
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package iapetus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	in := "\x1b[32mok\x1b[0m id=123e4567-e89b-12d3-a456-426614174000 at 2024-05-01T10:20:30.123Z"
+	got := NormalizeTimestamps(NormalizeUUIDs(NormalizeANSI(in)))
+	want := "ok id=<UUID> at <TIMESTAMP>"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	n, err := NormalizeRegexp(`pid=\d+`, "pid=<PID>")
+	if err != nil {
+		t.Fatalf("NormalizeRegexp failed: %v", err)
+	}
+	if got := n("pid=42"); got != "pid=<PID>" {
+		t.Errorf("got %q", got)
+	}
+	if _, err := NormalizeRegexp("(", ""); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+}
+
+func TestAssertOutputMatchesGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "out.golden")
+	task := &Task{Actual: Output{Output: "created pod 123e4567-e89b-12d3-a456-426614174000\nready\n"}}
+
+	err := AssertOutputMatchesGolden(path, NormalizeUUIDs)(task)
+	if err == nil || !strings.Contains(err.Error(), "--update-golden") {
+		t.Fatalf("expected missing golden file error, got %v", err)
+	}
+
+	UpdateGolden = true
+	err = AssertOutputMatchesGolden(path, NormalizeUUIDs)(task)
+	UpdateGolden = false
+	if err != nil {
+		t.Fatalf("expected golden update to succeed, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	if string(data) != "created pod <UUID>\nready\n" {
+		t.Errorf("unexpected golden contents %q", data)
+	}
+
+	task.Actual.Output = "created pod 00000000-0000-0000-0000-000000000000\r\nready"
+	if err := AssertOutputMatchesGolden(path, NormalizeUUIDs)(task); err != nil {
+		t.Errorf("expected normalized output to match golden, got %v", err)
+	}
+
+	task.Actual.Output = "created pod 00000000-0000-0000-0000-000000000000\nfailed"
+	err = AssertOutputMatchesGolden(path, NormalizeUUIDs)(task)
+	if err == nil || !strings.Contains(err.Error(), "-ready\n+failed") {
+		t.Errorf("expected unified diff in error, got %v", err)
+	}
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	if err := AssertOutputMatchesGolden(path, NormalizeUUIDs)(task); err != nil {
+		t.Errorf("expected env var to enable update mode, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package iapetus
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+// JSONCompareOptions controls how AssertOutputJsonEqualsWithOptions compares documents.
+type JSONCompareOptions struct {
+	// SkipPaths are dot-separated paths excluded from the comparison on both sides.
+	// A "*" segment matches any single key or array index, "**" matches any number
+	// of segments, and other segments may use path.Match globs (e.g. "kubectl.*").
+	// Array indexes may be written as "items.0" or "items[0]"; "items[*]" equals "items.*".
+	//
+	// Examples: "items.*.metadata.uid", "**.resourceVersion", "metadata.annotations.*".
+	SkipPaths []string
+	// IgnoreArrayOrder compares arrays as multisets (same elements, any order).
+	IgnoreArrayOrder bool
+	// FloatTolerance treats numbers as equal when they differ by at most this amount.
+	FloatTolerance float64
+}
+
+// jsonMismatch is a single difference found by compareJSON.
+type jsonMismatch struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// compareJSON diffs two decoded JSON documents and returns their differences.
+func compareJSON(expected, actual interface{}, opts JSONCompareOptions) ([]jsonMismatch, error) {
+	patterns := make([][]string, 0, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		patterns = append(patterns, splitSkipPath(p))
+	}
+	expected = pruneSkipPaths(expected, nil, patterns)
+	actual = pruneSkipPaths(actual, nil, patterns)
+
+	expNode, err := jd.NewJsonNode(expected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expectation: %w", err)
+	}
+	actNode, err := jd.NewJsonNode(actual)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	var metadata []jd.Metadata
+	if opts.IgnoreArrayOrder {
+		metadata = append(metadata, jd.MULTISET)
+	}
+	if opts.FloatTolerance > 0 {
+		metadata = append(metadata, jd.SetPrecision(opts.FloatTolerance))
+	}
+	var mismatches []jsonMismatch
+	for _, d := range expNode.Diff(actNode, metadata...) {
+		mismatches = append(mismatches, jsonMismatch{
+			Path:     formatJdPath(d.Path),
+			Expected: formatJdValues(d.OldValues),
+			Actual:   formatJdValues(d.NewValues),
+		})
+	}
+	return mismatches, nil
+}
+
+// formatJdPath renders a jd diff path as "a.b.0.c", dropping metadata nodes.
+func formatJdPath(p []jd.JsonNode) string {
+	var segments []string
+	for _, node := range p {
+		raw := node.Json()
+		var key string
+		if err := json.Unmarshal([]byte(raw), &key); err == nil {
+			segments = append(segments, key)
+			continue
+		}
+		if _, err := strconv.Atoi(raw); err == nil {
+			segments = append(segments, raw)
+		}
+	}
+	if len(segments) == 0 {
+		return "$"
+	}
+	return strings.Join(segments, ".")
+}
+
+// formatJdValues renders the values on one side of a diff ("<missing>" if absent).
+func formatJdValues(values []jd.JsonNode) string {
+	if len(values) == 0 {
+		return "<missing>"
+	}
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, v.Json())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// splitSkipPath splits a skip path into segments, normalizing "[n]" to ".n".
+func splitSkipPath(p string) []string {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	p = strings.ReplaceAll(p, "[", ".")
+	p = strings.ReplaceAll(p, "]", "")
+	var segments []string
+	for _, s := range strings.Split(p, ".") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// matchSkipPath reports whether the path segments match a skip pattern.
+func matchSkipPath(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSkipPath(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if !matchSkipSegment(pattern[0], segments[0]) {
+		return false
+	}
+	return matchSkipPath(pattern[1:], segments[1:])
+}
+
+// matchSkipSegment matches a single path segment against a pattern segment.
+func matchSkipSegment(pattern, segment string) bool {
+	if pattern == "*" || pattern == segment {
+		return true
+	}
+	ok, err := path.Match(pattern, segment)
+	return err == nil && ok
+}
+
+// pruneSkipPaths returns a copy of v without the values whose paths match any pattern.
+func pruneSkipPaths(v interface{}, prefix []string, patterns [][]string) interface{} {
+	if len(patterns) == 0 {
+		return v
+	}
+	switch node := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, child := range node {
+			p := append(append([]string{}, prefix...), k)
+			if skipPathMatches(patterns, p) {
+				continue
+			}
+			out[k] = pruneSkipPaths(child, p, patterns)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(node))
+		for i, child := range node {
+			p := append(append([]string{}, prefix...), strconv.Itoa(i))
+			if skipPathMatches(patterns, p) {
+				continue
+			}
+			out = append(out, pruneSkipPaths(child, p, patterns))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// skipPathMatches reports whether any pattern matches the path.
+func skipPathMatches(patterns [][]string, segments []string) bool {
+	for _, pattern := range patterns {
+		if matchSkipPath(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
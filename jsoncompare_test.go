@@ -0,0 +1,112 @@
+package iapetus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchSkipPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"metadata.uid", "metadata.uid", true},
+		{"metadata.uid", "metadata.name", false},
+		{"items.*.metadata.uid", "items.3.metadata.uid", true},
+		{"items[*].metadata.uid", "items.3.metadata.uid", true},
+		{"items[0].metadata.uid", "items.0.metadata.uid", true},
+		{"items.*.metadata.uid", "items.3.spec.uid", false},
+		{"**.resourceVersion", "resourceVersion", true},
+		{"**.resourceVersion", "items.0.metadata.resourceVersion", true},
+		{"items.**", "items.0.a.b", true},
+		{"metadata.creation*", "metadata.creationTimestamp", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"~"+tt.path, func(t *testing.T) {
+			got := matchSkipPath(splitSkipPath(tt.pattern), strings.Split(tt.path, "."))
+			if got != tt.want {
+				t.Errorf("matchSkipPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertOutputJsonEqualsWithOptions(t *testing.T) {
+	list := `{"items":[{"metadata":{"name":"a","uid":"1","resourceVersion":"10"}},{"metadata":{"name":"b","uid":"2","resourceVersion":"11"}}]}`
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+		opts     JSONCompareOptions
+		wantErr  string
+	}{
+		{
+			name:     "WildcardSkip",
+			actual:   list,
+			expected: `{"items":[{"metadata":{"name":"a","uid":"x","resourceVersion":"1"}},{"metadata":{"name":"b","uid":"y","resourceVersion":"2"}}]}`,
+			opts:     JSONCompareOptions{SkipPaths: []string{"items.*.metadata.uid", "**.resourceVersion"}},
+		},
+		{
+			name:     "WildcardSkipStillComparesOtherFields",
+			actual:   list,
+			expected: `{"items":[{"metadata":{"name":"a"}},{"metadata":{"name":"c"}}]}`,
+			opts:     JSONCompareOptions{SkipPaths: []string{"items.*.metadata.uid", "**.resourceVersion"}},
+			wantErr:  `mismatch at path items.1.metadata.name: expected "c", got "b"`,
+		},
+		{
+			name:     "ArrayOrderMatters",
+			actual:   `{"tags":["a","b","c"]}`,
+			expected: `{"tags":["c","a","b"]}`,
+			wantErr:  "mismatch",
+		},
+		{
+			name:     "IgnoreArrayOrder",
+			actual:   `{"tags":["a","b","c"]}`,
+			expected: `{"tags":["c","a","b"]}`,
+			opts:     JSONCompareOptions{IgnoreArrayOrder: true},
+		},
+		{
+			name:     "IgnoreArrayOrderStillDetectsMissing",
+			actual:   `{"tags":["a","b"]}`,
+			expected: `{"tags":["c","a","b"]}`,
+			opts:     JSONCompareOptions{IgnoreArrayOrder: true},
+			wantErr:  "mismatch",
+		},
+		{
+			name:     "FloatTolerance",
+			actual:   `{"cpu":0.5001}`,
+			expected: `{"cpu":0.5}`,
+			opts:     JSONCompareOptions{FloatTolerance: 0.001},
+		},
+		{
+			name:     "FloatOutsideTolerance",
+			actual:   `{"cpu":0.52}`,
+			expected: `{"cpu":0.5}`,
+			opts:     JSONCompareOptions{FloatTolerance: 0.001},
+			wantErr:  "mismatch at path cpu: expected 0.5, got 0.52",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Actual: Output{Output: tt.actual}}
+			err := AssertOutputJsonEqualsWithOptions(tt.expected, tt.opts)(task)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAssertOutputJsonEquals_ExactSkipPath(t *testing.T) {
+	task := &Task{Actual: Output{Output: `{"foo":{"bar":1,"baz":2}}`}}
+	if err := AssertOutputJsonEquals(`{"foo":{"bar":5,"baz":2}}`, "foo.bar")(task); err != nil {
+		t.Errorf("expected skip path to be honoured, got %v", err)
+	}
+}
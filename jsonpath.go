@@ -0,0 +1,201 @@
+package iapetus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath evaluates a simple JSON path against a decoded JSON document.
+//
+// Supported syntax: an optional leading "$", dot-separated object keys and
+// bracketed array indexes, e.g. "$.items[0].metadata.name" or "data.count".
+// Keys containing dots can be quoted in brackets: `labels["app.kubernetes.io/name"]`.
+// A "*" or "[*]" segment projects the rest of the path over every array element
+// (or object value) and yields a list, e.g. "items[*].status.phase".
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return evalJSONPath(doc, segments, path)
+}
+
+// pathNotFoundError reports a path that does not resolve in a document: a
+// missing key, an index out of range or a value that cannot be descended into.
+// Malformed paths are reported by parseJSONPath as plain errors.
+type pathNotFoundError struct {
+	path   string
+	reason string
+}
+
+func (e *pathNotFoundError) Error() string {
+	return fmt.Sprintf("path %q: %s", e.path, e.reason)
+}
+
+// notFound returns a pathNotFoundError for path.
+func notFound(path, format string, args ...interface{}) error {
+	return &pathNotFoundError{path: path, reason: fmt.Sprintf(format, args...)}
+}
+
+// evalJSONPath applies the remaining segments to cur.
+func evalJSONPath(cur interface{}, segments []jsonPathSegment, path string) (interface{}, error) {
+	for i, seg := range segments {
+		if seg.wildcard {
+			var items []interface{}
+			switch node := cur.(type) {
+			case []interface{}:
+				items = node
+			case map[string]interface{}:
+				keys := make([]string, 0, len(node))
+				for k := range node {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					items = append(items, node[k])
+				}
+			default:
+				return nil, notFound(path, "cannot apply wildcard to %s", jsonTypeName(cur))
+			}
+			out := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				v, err := evalJSONPath(item, segments[i+1:], path)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+			return out, nil
+		}
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			if seg.isIndex {
+				return nil, notFound(path, "cannot index object with [%d]", seg.index)
+			}
+			v, ok := node[seg.key]
+			if !ok {
+				return nil, notFound(path, "key %q not found", seg.key)
+			}
+			cur = v
+		case []interface{}:
+			if !seg.isIndex {
+				return nil, notFound(path, "cannot select key %q on array", seg.key)
+			}
+			idx := seg.index
+			if idx < 0 {
+				idx += len(node)
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, notFound(path, "index %d out of range (length %d)", seg.index, len(node))
+			}
+			cur = node[idx]
+		default:
+			return nil, notFound(path, "cannot descend into %s at segment %d", jsonTypeName(cur), i)
+		}
+	}
+	return cur, nil
+}
+
+// jsonPathSegment is a single key or index in a parsed JSON path.
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath splits a JSON path into key and index segments.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	p := strings.TrimSpace(path)
+	p = strings.TrimPrefix(p, "$")
+	var segments []jsonPathSegment
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed '['", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			if inner == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+				continue
+			}
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				segments = append(segments, jsonPathSegment{key: unquoted})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, inner)
+			}
+			segments = append(segments, jsonPathSegment{index: idx, isIndex: true})
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if p[:end] == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else {
+				segments = append(segments, jsonPathSegment{key: p[:end]})
+			}
+			p = p[end:]
+		}
+	}
+	return segments, nil
+}
+
+// jsonTypeName returns a short JSON type name for error messages.
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// normalizeJSONValue round-trips a Go value through JSON so that it can be
+// compared with values decoded by encoding/json (e.g. int becomes float64).
+func normalizeJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// jsonValuesEqual compares two values using JSON semantics.
+func jsonValuesEqual(a, b interface{}) bool {
+	na, err := normalizeJSONValue(a)
+	if err != nil {
+		return false
+	}
+	nb, err := normalizeJSONValue(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(na, nb)
+}
@@ -0,0 +1,515 @@
+package iapetus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PluginPrefix is the executable name prefix for external backend plugins.
+// An executable named "iapetus-backend-ssh" provides the backend "ssh".
+const PluginPrefix = "iapetus-backend-"
+
+// PluginDirEnv names the environment variable listing extra plugin directories
+// (separated by os.PathListSeparator), searched before PATH.
+const PluginDirEnv = "IAPETUS_PLUGIN_DIR"
+
+// Plugin protocol
+//
+// iapetus talks to plugins with JSON-RPC 2.0 over stdio, one process per call.
+// The plugin reads a single request line from stdin:
+//
+//	{"jsonrpc":"2.0","id":1,"method":"run","params":{"name":"t","command":"echo","args":["hi"],...}}
+//
+// Methods are "validate", "run" and "status". While handling "run" the plugin may
+// stream output as notifications, one JSON object per line on stdout:
+//
+//	{"jsonrpc":"2.0","method":"output","params":{"data":"partial output\n"}}
+//
+// and must finish with a response carrying the same id:
+//
+//	{"jsonrpc":"2.0","id":1,"result":{"exit_code":0,"output":"hi\n"}}
+//	{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"image required"}}
+//
+// The "status" result is {"status":"available"}; "validate" returns an empty result
+// or an error. Lines on stdout that are not JSON are ignored. Go plugins can use
+// ServePlugin. Streamed output is forwarded to the host task's OutputWriter.
+
+// PluginTask is the JSON representation of a Task sent to plugins.
+type PluginTask struct {
+	Name       string            `json:"name"`
+	Command    string            `json:"command"`
+	Args       []string          `json:"args,omitempty"`
+	EnvMap     map[string]string `json:"env_map,omitempty"`
+	Image      string            `json:"image,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+	Timeout    string            `json:"timeout,omitempty"`
+}
+
+// PluginRunResult is the result of the "run" method.
+type PluginRunResult struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+	Error    string `json:"error,omitempty"`
+	// Env holds environment side effects exported by the task (optional).
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// pluginMessage is any JSON-RPC 2.0 message exchanged with a plugin.
+type pluginMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *pluginRPCError `json:"error,omitempty"`
+}
+
+// pluginRPCError is a JSON-RPC 2.0 error object.
+// For "run", Data may carry a PluginRunResult describing the failed execution.
+type pluginRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// pluginCallError is returned by PluginBackend.call when the plugin responds with an error.
+type pluginCallError struct {
+	message string
+	data    json.RawMessage
+}
+
+func (e *pluginCallError) Error() string {
+	return e.message
+}
+
+// pluginOutputParams are the params of an "output" notification.
+type pluginOutputParams struct {
+	Data string `json:"data"`
+}
+
+// pluginStatusResult is the result of the "status" method.
+type pluginStatusResult struct {
+	Status string `json:"status"`
+}
+
+// pluginTaskFrom converts a Task to its plugin representation.
+func pluginTaskFrom(t *Task) *PluginTask {
+	pt := &PluginTask{
+		Name:       t.Name,
+		Command:    t.Command,
+		Args:       t.Args,
+		EnvMap:     t.EnvMap,
+		Image:      t.Image,
+		WorkingDir: t.WorkingDir,
+	}
+	if t.Timeout > 0 {
+		pt.Timeout = t.Timeout.String()
+	}
+	return pt
+}
+
+// toTask converts a plugin task back to a Task (used by ServePlugin).
+func (pt *PluginTask) toTask() *Task {
+	t := &Task{
+		Name:       pt.Name,
+		Command:    pt.Command,
+		Args:       pt.Args,
+		EnvMap:     pt.EnvMap,
+		Image:      pt.Image,
+		WorkingDir: pt.WorkingDir,
+		logger:     zap.NewNop(),
+	}
+	if d, err := time.ParseDuration(pt.Timeout); err == nil {
+		t.Timeout = d
+	}
+	return t
+}
+
+// PluginBackend exposes an external plugin executable through the Backend interface.
+type PluginBackend struct {
+	name string
+	path string
+	// StatusTimeout bounds the "status" and "validate" calls. Defaults to 10s.
+	StatusTimeout time.Duration
+}
+
+// NewPluginBackend creates a backend that delegates to the plugin executable at path.
+func NewPluginBackend(name, path string) *PluginBackend {
+	return &PluginBackend{name: name, path: path, StatusTimeout: 10 * time.Second}
+}
+
+// Path returns the plugin executable path.
+func (p *PluginBackend) Path() string {
+	return p.path
+}
+
+// call runs the plugin once for a single method and returns its result.
+// Notifications received before the response are passed to onNotify.
+// If t is not nil, ctx derives from t.Context() and the plugin is stopped like a
+// bash task when it is done: its process group receives SIGTERM and is killed
+// after the task's grace period (see setGracefulCancel).
+func (p *PluginBackend) call(ctx context.Context, t *Task, method string, params interface{}, onNotify func(pluginMessage)) (json.RawMessage, error) {
+	id := 1
+	req := pluginMessage{JSONRPC: "2.0", ID: &id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: failed to encode params: %w", p.name, err)
+		}
+		req.Params = data
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to encode request: %w", p.name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.path)
+	var gc *gracefulCommand
+	if t != nil {
+		setProcessGroup(cmd)
+		gc = setGracefulCancel(cmd, t, true, nil, nil)
+	}
+	cmd.Stdin = bytes.NewReader(append(line, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	start := cmd.Start
+	if gc != nil {
+		start = gc.start
+	}
+	if err := start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to start %s: %w", p.name, p.path, err)
+	}
+
+	var resp *pluginMessage
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var msg pluginMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.JSONRPC != "2.0" {
+			continue
+		}
+		if msg.ID != nil && *msg.ID == id && msg.Method == "" {
+			resp = &msg
+			continue
+		}
+		if msg.Method != "" && onNotify != nil {
+			onNotify(msg)
+		}
+	}
+	// Drain anything left so the process can exit
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+	if gc != nil {
+		gc.release()
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if resp == nil {
+		msg := strings.TrimSpace(stderr.String())
+		if waitErr != nil {
+			return nil, fmt.Errorf("plugin %s: %s exited without a response: %v: %s", p.name, method, waitErr, msg)
+		}
+		return nil, fmt.Errorf("plugin %s: %s returned no response: %s", p.name, method, msg)
+	}
+	if resp.Error != nil {
+		return nil, &pluginCallError{message: resp.Error.Message, data: resp.Error.Data}
+	}
+	return resp.Result, nil
+}
+
+// ValidateTask asks the plugin to validate the task.
+func (p *PluginBackend) ValidateTask(task *Task) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.statusTimeout())
+	defer cancel()
+	_, err := p.call(ctx, nil, "validate", pluginTaskFrom(task), nil)
+	return err
+}
+
+// RunTask runs the task in the plugin, streaming output notifications to the task
+// logger and the task's OutputWriter. If the workflow is cancelled or times out,
+// the plugin receives SIGTERM and is killed after the task's grace period.
+// Assertions are evaluated in-process once the plugin returns.
+func (p *PluginBackend) RunTask(task *Task) error {
+	task.EnsureDefaults()
+	if task.Timeout == 0 {
+		task.Timeout = DefaultTaskTimeout
+	}
+	ctx, cancel := context.WithTimeout(task.Context(), task.Timeout)
+	defer cancel()
+
+	var streamed strings.Builder
+	onNotify := func(msg pluginMessage) {
+		if msg.Method != "output" {
+			return
+		}
+		var params pluginOutputParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return
+		}
+		streamed.WriteString(params.Data)
+		task.Logger().Debug("Plugin output", zap.String("task", task.Name), zap.String("plugin", p.name), zap.String("data", params.Data))
+		if task.OutputWriter != nil {
+			_, _ = io.WriteString(task.OutputWriter, params.Data)
+		}
+	}
+	start := time.Now()
+	raw, err := p.call(ctx, task, "run", pluginTaskFrom(task), onNotify)
+	duration := time.Since(start)
+	if err != nil {
+		task.Actual = Output{ExitCode: -1, Output: streamed.String(), Error: err.Error(), Duration: duration}
+		var callErr *pluginCallError
+		if errors.As(err, &callErr) && len(callErr.data) > 0 {
+			var res PluginRunResult
+			if json.Unmarshal(callErr.data, &res) == nil {
+				task.Actual = Output{ExitCode: res.ExitCode, Output: res.Output, Error: res.Error, Env: res.Env, Duration: duration}
+			}
+		}
+		if ctx.Err() != nil {
+			task.Logger().Error("Task timed out", zap.String("task", task.Name), zap.Duration("timeout", task.Timeout))
+			return task.contextError()
+		}
+		return fmt.Errorf("plugin %s run failed: %w", p.name, err)
+	}
+	var res PluginRunResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return fmt.Errorf("plugin %s: invalid run result: %w", p.name, err)
+	}
+	task.Actual = Output{ExitCode: res.ExitCode, Output: res.Output, Error: res.Error, Env: res.Env, Duration: duration}
+	if task.Actual.Output == "" {
+		task.Actual.Output = streamed.String()
+	}
+	// Run assertions and propagate errors
+	err = RunAssertions(task)
+	if err != nil {
+		task.Logger().Error("Assertion(s) failed", zap.String("task", task.Name), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetName returns the plugin backend name.
+func (p *PluginBackend) GetName() string {
+	return p.name
+}
+
+// GetStatus asks the plugin for its status, or reports "unavailable: <reason>" if it cannot be reached.
+func (p *PluginBackend) GetStatus() string {
+	ctx, cancel := context.WithTimeout(context.Background(), p.statusTimeout())
+	defer cancel()
+	raw, err := p.call(ctx, nil, "status", nil, nil)
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	var res pluginStatusResult
+	if err := json.Unmarshal(raw, &res); err != nil || res.Status == "" {
+		return "unavailable: invalid status response"
+	}
+	return res.Status
+}
+
+func (p *PluginBackend) statusTimeout() time.Duration {
+	if p.StatusTimeout > 0 {
+		return p.StatusTimeout
+	}
+	return 10 * time.Second
+}
+
+// FindPlugins returns plugin executables keyed by backend name.
+//
+// Directories are searched in order: dirs, then IAPETUS_PLUGIN_DIR, then PATH.
+// The first executable found for a name wins.
+func FindPlugins(dirs ...string) map[string]string {
+	search := append([]string{}, dirs...)
+	if env := os.Getenv(PluginDirEnv); env != "" {
+		search = append(search, filepath.SplitList(env)...)
+	}
+	search = append(search, filepath.SplitList(os.Getenv("PATH"))...)
+
+	found := make(map[string]string)
+	for _, dir := range search {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+			if !strings.HasPrefix(name, PluginPrefix) || e.IsDir() {
+				continue
+			}
+			backend := strings.TrimPrefix(name, PluginPrefix)
+			if backend == "" {
+				continue
+			}
+			if _, seen := found[backend]; seen {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			found[backend] = path
+		}
+	}
+	return found
+}
+
+// DiscoverPlugins finds plugin executables (see FindPlugins) and registers each one
+// as a PluginBackend in the global registry. Names that are already registered,
+// including built-in backends, are left untouched.
+// Returns the names of the newly registered backends.
+func DiscoverPlugins(dirs ...string) []string {
+	var registered []string
+	for name, path := range FindPlugins(dirs...) {
+		if GetBackend(name) != nil {
+			continue
+		}
+		if err := RegisterBackend(name, NewPluginBackend(name, path)); err == nil {
+			registered = append(registered, name)
+		}
+	}
+	return registered
+}
+
+// ServePlugin implements the plugin side of the protocol for a Go Backend.
+// Output the backend writes to Task.OutputWriter while running is streamed to
+// iapetus as it is produced; otherwise Actual.Output is sent once the run ends.
+// When iapetus stops the plugin with SIGTERM (or it receives SIGINT), the
+// context of the running task (Task.Context) is cancelled, so the backend can
+// stop its work and clean up before it is killed.
+// Call it from the main function of an iapetus-backend-<name> executable:
+//
+//	func main() {
+//	    if err := iapetus.ServePlugin(&MyBackend{}); err != nil {
+//	        os.Exit(1)
+//	    }
+//	}
+func ServePlugin(backend Backend) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return servePlugin(ctx, os.Stdin, os.Stdout, backend)
+}
+
+// pluginOutputWriter sends everything written to it as "output" notifications,
+// so that output a backend streams to Task.OutputWriter reaches the host as it
+// is produced.
+type pluginOutputWriter struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	streamed bool
+	err      error
+}
+
+// Write sends p as one "output" notification.
+func (w *pluginOutputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	params, _ := json.Marshal(pluginOutputParams{Data: string(p)})
+	if w.err = w.enc.Encode(pluginMessage{JSONRPC: "2.0", Method: "output", Params: params}); w.err != nil {
+		return 0, w.err
+	}
+	w.streamed = true
+	return len(p), nil
+}
+
+// finish sends output in one notification if the backend did not stream any,
+// and reports a failure to send notifications.
+func (w *pluginOutputWriter) finish(output string) error {
+	w.mu.Lock()
+	streamed, err := w.streamed, w.err
+	w.mu.Unlock()
+	if err != nil || streamed || output == "" {
+		return err
+	}
+	_, err = w.Write([]byte(output))
+	return err
+}
+
+// servePlugin handles a single request read from r and writes messages to w.
+// ctx becomes the context of the task for a "run" request.
+func servePlugin(ctx context.Context, r io.Reader, w io.Writer, backend Backend) error {
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	var req pluginMessage
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fmt.Errorf("invalid plugin request: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	respond := func(result interface{}, callErr error) error {
+		resp := pluginMessage{JSONRPC: "2.0", ID: req.ID}
+		if callErr != nil {
+			resp.Error = &pluginRPCError{Code: -32000, Message: callErr.Error()}
+			if result != nil {
+				data, err := json.Marshal(result)
+				if err != nil {
+					return err
+				}
+				resp.Error.Data = data
+			}
+		} else {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			resp.Result = data
+		}
+		return enc.Encode(resp)
+	}
+	var pt PluginTask
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &pt); err != nil {
+			return respond(nil, fmt.Errorf("invalid params: %w", err))
+		}
+	}
+	switch req.Method {
+	case "status":
+		return respond(pluginStatusResult{Status: backend.GetStatus()}, nil)
+	case "validate":
+		return respond(struct{}{}, backend.ValidateTask(pt.toTask()))
+	case "run":
+		task := pt.toTask()
+		out := &pluginOutputWriter{enc: enc}
+		task.OutputWriter = out
+		task.ctx = ctx
+		runErr := backend.RunTask(task)
+		if err := out.finish(task.Actual.Output); err != nil {
+			return err
+		}
+		res := PluginRunResult{ExitCode: task.Actual.ExitCode, Output: task.Actual.Output, Error: task.Actual.Error, Env: task.Actual.Env}
+		if runErr != nil && res.Error == "" {
+			res.Error = runErr.Error()
+		}
+		return respond(res, runErr)
+	default:
+		return respond(nil, fmt.Errorf("unknown method %q", req.Method))
+	}
+}
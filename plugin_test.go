@@ -0,0 +1,229 @@
+package iapetus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// echoPluginBackend is served by the helper process below.
+type echoPluginBackend struct{}
+
+func (e *echoPluginBackend) RunTask(task *Task) error {
+	task.Actual.Output = task.Command + " " + strings.Join(task.Args, " ") + " " + task.EnvMap["FOO"]
+	switch task.Command {
+	case "fail":
+		task.Actual.ExitCode = 3
+		return errors.New("command failed")
+	case "wait-cancel":
+		// Wait to be stopped, then record the cleanup in the file named by the first arg.
+		select {
+		case <-task.Context().Done():
+		case <-time.After(30 * time.Second):
+			return errors.New("not cancelled")
+		}
+		if err := os.WriteFile(task.Args[0], []byte("cleaned up"), 0o644); err != nil {
+			return err
+		}
+		return task.Context().Err()
+	}
+	return nil
+}
+
+func (e *echoPluginBackend) ValidateTask(task *Task) error {
+	if task.Command == "invalid" {
+		return errors.New("invalid command")
+	}
+	return nil
+}
+
+func (e *echoPluginBackend) GetName() string   { return "echo" }
+func (e *echoPluginBackend) GetStatus() string { return "available" }
+
+// TestPluginHelperProcess is not a real test: it is executed as the plugin binary.
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("IAPETUS_PLUGIN_HELPER") != "1" {
+		return
+	}
+	if err := ServePlugin(&echoPluginBackend{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// writeTestPlugin creates an iapetus-backend-<name> executable that re-runs the test binary as a plugin.
+func writeTestPlugin(t *testing.T, name string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test helper requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nIAPETUS_PLUGIN_HELPER=1 exec %q -test.run='^TestPluginHelperProcess$'\n", os.Args[0])
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+name), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return dir
+}
+
+func TestFindPlugins(t *testing.T) {
+	dir := writeTestPlugin(t, "echo")
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+"noexec"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	found := FindPlugins(dir)
+	if found["echo"] != filepath.Join(dir, PluginPrefix+"echo") {
+		t.Errorf("expected echo plugin to be found, got %v", found)
+	}
+	if _, ok := found["noexec"]; ok {
+		t.Errorf("non-executable files must be ignored")
+	}
+}
+
+func TestDiscoverPlugins_RegistersBackend(t *testing.T) {
+	dir := writeTestPlugin(t, "plugintest")
+	names := DiscoverPlugins(dir)
+	t.Cleanup(func() { UnregisterBackend("plugintest") })
+	if len(names) != 1 || names[0] != "plugintest" {
+		t.Fatalf("expected plugintest to be registered, got %v", names)
+	}
+	if _, ok := GetBackend("plugintest").(*PluginBackend); !ok {
+		t.Fatalf("expected a PluginBackend")
+	}
+	if again := DiscoverPlugins(dir); len(again) != 0 {
+		t.Errorf("expected already-registered plugins to be skipped, got %v", again)
+	}
+}
+
+func TestPluginBackend_Protocol(t *testing.T) {
+	dir := writeTestPlugin(t, "echo")
+	b := NewPluginBackend("echo", filepath.Join(dir, PluginPrefix+"echo"))
+
+	if status := b.GetStatus(); status != "available" {
+		t.Errorf("expected status 'available', got %q", status)
+	}
+
+	task := NewTask("plugin", 10*time.Second, zap.NewNop())
+	task.Command = "hello"
+	task.Args = []string{"world"}
+	task.EnvMap = map[string]string{"FOO": "bar"}
+	if err := b.ValidateTask(task); err != nil {
+		t.Fatalf("expected valid task, got %v", err)
+	}
+	task.AssertOutputEquals("hello world bar")
+	var streamed bytes.Buffer
+	task.OutputWriter = &streamed
+	if err := b.RunTask(task); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if streamed.String() != "hello world bar" {
+		t.Errorf("expected output to be streamed to the task's writer, got %q", streamed.String())
+	}
+
+	task.Command = "invalid"
+	if err := b.ValidateTask(task); err == nil || !strings.Contains(err.Error(), "invalid command") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	failing := NewTask("plugin-fail", 10*time.Second, zap.NewNop())
+	failing.Command = "fail"
+	err := b.RunTask(failing)
+	if err == nil || !strings.Contains(err.Error(), "command failed") {
+		t.Errorf("expected run error, got %v", err)
+	}
+	if failing.Actual.ExitCode != 3 {
+		t.Errorf("expected exit code 3 from plugin, got %d", failing.Actual.ExitCode)
+	}
+}
+
+func TestPluginBackend_UnavailableStatus(t *testing.T) {
+	b := NewPluginBackend("missing", filepath.Join(t.TempDir(), "does-not-exist"))
+	if status := b.GetStatus(); !backendUnavailable(status) {
+		t.Errorf("expected unavailable status, got %q", status)
+	}
+}
+
+func TestServePlugin_StreamsOutput(t *testing.T) {
+	req := `{"jsonrpc":"2.0","id":1,"method":"run","params":{"name":"t","command":"hi","args":["there"]}}` + "\n"
+	var out bytes.Buffer
+	if err := servePlugin(context.Background(), strings.NewReader(req), &out, &echoPluginBackend{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected notification and response, got %q", out.String())
+	}
+	var note, resp pluginMessage
+	if err := json.Unmarshal([]byte(lines[0]), &note); err != nil || note.Method != "output" {
+		t.Errorf("expected output notification, got %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &resp); err != nil || resp.ID == nil || *resp.ID != 1 || resp.Result == nil {
+		t.Errorf("expected response with id 1, got %s", lines[1])
+	}
+}
+
+// streamingPluginBackend writes output in two chunks, waiting for release in between.
+type streamingPluginBackend struct {
+	echoPluginBackend
+	release chan struct{}
+}
+
+func (s *streamingPluginBackend) RunTask(task *Task) error {
+	fmt.Fprint(task.OutputWriter, "one\n")
+	<-s.release
+	fmt.Fprint(task.OutputWriter, "two\n")
+	task.Actual.Output = "one\ntwo\n"
+	return nil
+}
+
+func TestServePlugin_StreamsWhileRunning(t *testing.T) {
+	req := `{"jsonrpc":"2.0","id":1,"method":"run","params":{"name":"t","command":"hi"}}` + "\n"
+	backend := &streamingPluginBackend{release: make(chan struct{})}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- servePlugin(context.Background(), strings.NewReader(req), pw, backend)
+		pw.Close()
+	}()
+	lines := bufio.NewScanner(pr)
+	data := func() string {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("expected another message: %v", lines.Err())
+		}
+		var note pluginMessage
+		var params pluginOutputParams
+		if err := json.Unmarshal(lines.Bytes(), &note); err != nil || note.Method != "output" || json.Unmarshal(note.Params, &params) != nil {
+			t.Fatalf("expected output notification, got %s", lines.Text())
+		}
+		return params.Data
+	}
+
+	// The first chunk arrives while the backend is still running.
+	if got := data(); got != "one\n" {
+		t.Errorf("expected first chunk, got %q", got)
+	}
+	close(backend.release)
+	if got := data(); got != "two\n" {
+		t.Errorf("expected second chunk, got %q", got)
+	}
+	var resp pluginMessage
+	if !lines.Scan() || json.Unmarshal(lines.Bytes(), &resp) != nil || resp.ID == nil || resp.Result == nil {
+		t.Fatalf("expected response, got %s", lines.Text())
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package iapetus
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PreflightIssue describes a problem found before a workflow starts.
+type PreflightIssue struct {
+	// Task is the affected task, or empty if the issue concerns a backend as a whole.
+	Task string
+	// Backend is the backend the issue relates to.
+	Backend string
+	// Reason explains what is wrong.
+	Reason string
+}
+
+// String formats the issue for reports.
+func (i PreflightIssue) String() string {
+	if i.Task == "" {
+		return fmt.Sprintf("backend %s: %s", i.Backend, i.Reason)
+	}
+	return fmt.Sprintf("task %s (backend %s): %s", i.Task, i.Backend, i.Reason)
+}
+
+// PreflightError is returned when preflight checks find one or more issues.
+// It lists every issue so they can be fixed in one go.
+type PreflightError struct {
+	Issues []PreflightIssue
+}
+
+// Error implements the error interface for PreflightError.
+func (e *PreflightError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, "  - "+issue.String())
+	}
+	return fmt.Sprintf("preflight failed with %d issue(s):\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// backendUnavailable reports whether a GetStatus value means the backend cannot run tasks.
+// Statuses starting with "unavailable" (e.g. "unavailable: docker not found") count as unavailable.
+func backendUnavailable(status string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(status)), "unavailable")
+}
+
+// Preflight checks that every backend used by the workflow exists and is available,
+// and that every task passes its backend's ValidateTask, before anything runs.
+//
+// Returns a *PreflightError listing all issues, or nil if the workflow can start.
+// Workflow.Run calls Preflight automatically unless SkipPreflight is set.
+func (w *Workflow) Preflight() error {
+	return w.preflight(nil)
+}
+
+// preflight checks the tasks not in skip; see Preflight.
+func (w *Workflow) preflight(skip map[string]string) error {
+	var issues []PreflightIssue
+	statuses := make(map[string]string)
+	for i := range w.Steps {
+		task := &w.Steps[i]
+		if _, skipped := skip[task.Name]; skipped {
+			continue
+		}
+		name := task.Backend
+		if name == "" {
+			name = w.Backend
+		}
+		if name == "" {
+			name = DefaultBackend
+		}
+		backend := w.GetBackend(name)
+		if backend == nil {
+			issues = append(issues, PreflightIssue{Task: task.Name, Backend: name, Reason: fmt.Sprintf("backend %s not found", name)})
+			continue
+		}
+		status, checked := statuses[name]
+		if !checked {
+			status = backend.GetStatus()
+			statuses[name] = status
+			if backendUnavailable(status) {
+				issues = append(issues, PreflightIssue{Backend: name, Reason: status})
+			}
+		}
+		if backendUnavailable(status) {
+			continue
+		}
+		if err := backend.ValidateTask(task); err != nil {
+			issues = append(issues, PreflightIssue{Task: task.Name, Backend: name, Reason: err.Error()})
+		}
+	}
+	if len(issues) > 0 {
+		return &PreflightError{Issues: issues}
+	}
+	w.logger.Debug("Preflight passed", zap.String("workflow", w.Name), zap.Int("backends", len(statuses)))
+	return nil
+}
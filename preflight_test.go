@@ -0,0 +1,83 @@
+package iapetus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type validatingBackend struct {
+	mockBackend
+	validateErr error
+}
+
+func (v *validatingBackend) ValidateTask(task *Task) error {
+	if task.Image == "" {
+		return v.validateErr
+	}
+	return nil
+}
+
+func TestWorkflow_PreflightRefusesUnavailableBackend(t *testing.T) {
+	ranFirst := false
+	w := NewWorkflow("preflight", zap.NewNop())
+	_ = w.RegisterBackend("ok", &mockBackend{name: "ok", status: "available", called: &ranFirst})
+	_ = w.RegisterBackend("down", &mockBackend{name: "down", status: "unavailable: daemon not running"})
+	w.AddTask(Task{Name: "first", Command: "echo", Backend: "ok"})
+	w.AddTask(Task{Name: "second", Command: "echo", Backend: "down", Depends: []string{"first"}})
+	w.AddTask(Task{Name: "third", Command: "echo", Backend: "down", Depends: []string{"first"}})
+
+	err := w.Run()
+	var pe *PreflightError
+	if !errors.As(err.(*WorkflowError).Err, &pe) {
+		t.Fatalf("expected PreflightError, got %v", err)
+	}
+	if len(pe.Issues) != 1 || pe.Issues[0].Backend != "down" {
+		t.Errorf("expected a single issue for backend 'down', got %+v", pe.Issues)
+	}
+	if !strings.Contains(err.Error(), "daemon not running") {
+		t.Errorf("expected status in report, got %v", err)
+	}
+	if ranFirst {
+		t.Errorf("no task should run when preflight fails")
+	}
+}
+
+func TestWorkflow_PreflightCollectsAllIssues(t *testing.T) {
+	w := NewWorkflow("preflight-validate", zap.NewNop())
+	_ = w.RegisterBackend("img", &validatingBackend{mockBackend: mockBackend{name: "img", status: "available"}, validateErr: errors.New("image required")})
+	w.AddTask(Task{Name: "a", Command: "echo", Backend: "img"})
+	w.AddTask(Task{Name: "b", Command: "echo", Backend: "img", Image: "alpine"})
+	w.AddTask(Task{Name: "c", Command: "echo", Backend: "img"})
+	w.AddTask(Task{Name: "d", Command: "echo", Backend: "missing"})
+
+	err := w.Preflight()
+	var pe *PreflightError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected PreflightError, got %v", err)
+	}
+	if len(pe.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %d: %v", len(pe.Issues), err)
+	}
+	if pe.Issues[0].Task != "a" || pe.Issues[1].Task != "c" {
+		t.Errorf("unexpected issues: %+v", pe.Issues)
+	}
+	if !strings.Contains(pe.Issues[2].Reason, "backend missing not found") {
+		t.Errorf("expected missing backend issue, got %+v", pe.Issues[2])
+	}
+}
+
+func TestWorkflow_SkipPreflight(t *testing.T) {
+	called := false
+	w := NewWorkflow("skip-preflight", zap.NewNop()).SetSkipPreflight(true)
+	_ = w.RegisterBackend("down", &mockBackend{name: "down", status: "unavailable", called: &called})
+	w.AddTask(Task{Name: "a", Command: "echo", Backend: "down"})
+	if err := w.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !called {
+		t.Errorf("expected task to run when preflight is skipped")
+	}
+}
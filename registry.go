@@ -0,0 +1,102 @@
+package iapetus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// BackendRegistry is a concurrency-safe set of backends keyed by name.
+//
+// A global registry backs RegisterBackend/GetBackend; each Workflow can also
+// hold its own registry whose entries take precedence over the global one.
+type BackendRegistry struct {
+	mu       sync.RWMutex
+	backends map[string]Backend
+}
+
+// NewBackendRegistry creates an empty BackendRegistry.
+func NewBackendRegistry() *BackendRegistry {
+	return &BackendRegistry{backends: make(map[string]Backend)}
+}
+
+// Register adds a backend under name.
+// Returns an error if the name is empty, the backend is nil, or the name is already taken.
+func (r *BackendRegistry) Register(name string, backend Backend) error {
+	if name == "" {
+		return fmt.Errorf("backend name must not be empty")
+	}
+	if backend == nil {
+		return fmt.Errorf("backend %s is nil", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.backends[name]; exists {
+		return fmt.Errorf("backend %s already registered", name)
+	}
+	r.backends[name] = backend
+	return nil
+}
+
+// Unregister removes the backend registered under name, if any.
+func (r *BackendRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.backends, name)
+}
+
+// Get retrieves a backend by name, or nil if not found.
+func (r *BackendRegistry) Get(name string) Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.backends[name]
+}
+
+// List returns the names of all registered backends in sorted order.
+func (r *BackendRegistry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// backendRegistry holds all globally registered backends by name.
+var backendRegistry = NewBackendRegistry()
+
+// RegisterBackend registers a backend plugin by name in the global registry.
+// Returns an error if a backend with the same name is already registered.
+//
+// Registering a taken name used to replace the existing backend; it now leaves
+// it in place, so callers that ignore the error keep the old backend. Call
+// UnregisterBackend first to replace one, or use MustRegisterBackend in init().
+func RegisterBackend(name string, backend Backend) error {
+	return backendRegistry.Register(name, backend)
+}
+
+// MustRegisterBackend is like RegisterBackend but panics if registration fails.
+//
+// Plugin authors: call this in your plugin's init() function.
+func MustRegisterBackend(name string, backend Backend) {
+	if err := RegisterBackend(name, backend); err != nil {
+		panic(err)
+	}
+}
+
+// UnregisterBackend removes a backend from the global registry.
+func UnregisterBackend(name string) {
+	backendRegistry.Unregister(name)
+}
+
+// GetBackend retrieves a backend by name from the global registry, or nil if not found.
+func GetBackend(name string) Backend {
+	return backendRegistry.Get(name)
+}
+
+// ListBackends returns the names of all globally registered backends in sorted order.
+func ListBackends() []string {
+	return backendRegistry.List()
+}
@@ -0,0 +1,114 @@
+package iapetus
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestBackendRegistry_RegisterDuplicate(t *testing.T) {
+	r := NewBackendRegistry()
+	if err := r.Register("a", &mockBackend{name: "a"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err := r.Register("a", &mockBackend{name: "a"})
+	if err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected duplicate registration error, got %v", err)
+	}
+	if err := r.Register("", &mockBackend{}); err == nil {
+		t.Errorf("expected error for empty name")
+	}
+	if err := r.Register("nil", nil); err == nil {
+		t.Errorf("expected error for nil backend")
+	}
+}
+
+func TestMustRegisterBackend(t *testing.T) {
+	UnregisterBackend("must")
+	t.Cleanup(func() { UnregisterBackend("must") })
+	MustRegisterBackend("must", &mockBackend{name: "must"})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "already registered") {
+			t.Errorf("expected panic on duplicate registration, got %v", r)
+		}
+	}()
+	MustRegisterBackend("must", &mockBackend{name: "must"})
+}
+
+func TestBackendRegistry_ListAndUnregister(t *testing.T) {
+	r := NewBackendRegistry()
+	for _, name := range []string{"c", "a", "b"} {
+		if err := r.Register(name, &mockBackend{name: name}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if got := r.List(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("expected sorted names, got %v", got)
+	}
+	r.Unregister("b")
+	if r.Get("b") != nil {
+		t.Errorf("expected b to be unregistered")
+	}
+	if err := r.Register("b", &mockBackend{name: "b"}); err != nil {
+		t.Errorf("expected re-registration after unregister to succeed, got %v", err)
+	}
+}
+
+func TestBackendRegistry_Concurrent(t *testing.T) {
+	r := NewBackendRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("b%d", i)
+			_ = r.Register(name, &mockBackend{name: name})
+			_ = r.Get(name)
+			_ = r.List()
+		}(i)
+	}
+	wg.Wait()
+	if got := len(r.List()); got != 50 {
+		t.Errorf("expected 50 backends, got %d", got)
+	}
+}
+
+func TestListBackends_BuiltIns(t *testing.T) {
+	names := ListBackends()
+	for _, want := range []string{"bash", "docker", "func", "http", "kubernetes"} {
+		found := false
+		for _, n := range names {
+			if n == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected built-in backend %q in %v", want, names)
+		}
+	}
+}
+
+func TestWorkflow_RegisterBackendOverridesGlobal(t *testing.T) {
+	called := false
+	w := NewWorkflow("override", zap.NewNop())
+	if err := w.RegisterBackend("bash", &mockBackend{name: "bash", called: &called}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := w.RegisterBackend("bash", &mockBackend{name: "bash"}); err == nil {
+		t.Errorf("expected duplicate registration error on workflow registry")
+	}
+	w.AddTask(Task{Name: "a", Command: "echo"})
+	if err := w.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !called {
+		t.Errorf("expected workflow backend to override the global one")
+	}
+	if _, ok := GetBackend("bash").(*mockBackend); ok {
+		t.Errorf("workflow registration must not leak into the global registry")
+	}
+}
@@ -0,0 +1,121 @@
+package iapetus
+
+import (
+	"time"
+)
+
+// TaskStatus is the outcome of a task in a workflow run.
+type TaskStatus string
+
+const (
+	// TaskStatusPending means the task never started (e.g. the run stopped first).
+	TaskStatusPending TaskStatus = "pending"
+	// TaskStatusSucceeded means the task ran and all fatal assertions passed.
+	TaskStatusSucceeded TaskStatus = "succeeded"
+	// TaskStatusFailed means the task failed after all retries.
+	TaskStatusFailed TaskStatus = "failed"
+	// TaskStatusSkipped means the task succeeded in the resumed run and was not run again.
+	TaskStatusSkipped TaskStatus = "skipped"
+	// TaskStatusPruned means the workflow's Selection excluded the task.
+	TaskStatusPruned TaskStatus = "pruned"
+	// TaskStatusTimedOut means the workflow's Timeout expired before the task finished.
+	TaskStatusTimedOut TaskStatus = "timed_out"
+	// TaskStatusCancelled means the run was cancelled before the task finished.
+	TaskStatusCancelled TaskStatus = "cancelled"
+)
+
+// TaskResult summarizes one task of a workflow run.
+type TaskResult struct {
+	Name   string
+	Status TaskStatus
+	// Duration is the wall-clock time of the task including retries.
+	Duration time.Duration
+	// Err is the task's error if it failed.
+	Err error
+	// Warnings are the non-fatal assertion findings of the last attempt.
+	Warnings []*AssertionError
+	// Cache is CacheHit or CacheMiss for tasks that use the cache, and empty otherwise.
+	Cache CacheStatus
+	// Workflow is the nested result of a sub-workflow task.
+	Workflow *RunResult
+	// GeneratedBy names the generator that spawned the task, if any (see Generator).
+	GeneratedBy string
+}
+
+// Category classifies why the task did not succeed (see Categorize): tasks
+// stopped by the workflow's Timeout or cancellation are CategoryInterrupted,
+// and tasks without an error have no category.
+func (r TaskResult) Category() FailureCategory {
+	if r.Status == TaskStatusTimedOut || r.Status == TaskStatusCancelled {
+		return CategoryInterrupted
+	}
+	return Categorize(r.Err)
+}
+
+// RunResult summarizes a workflow run. Tasks are listed in step order, with tasks
+// spawned by a generator right after it.
+type RunResult struct {
+	Workflow  string
+	RunID     string
+	StartedAt time.Time
+	Duration  time.Duration
+	Tasks     []TaskResult
+	// Err is the error returned by Workflow.Run, if any.
+	Err error
+}
+
+// Task returns the result of the named task, or nil if it is not part of the run.
+func (r *RunResult) Task(name string) *TaskResult {
+	for i := range r.Tasks {
+		if r.Tasks[i].Name == name {
+			return &r.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// Warnings returns the warnings of all tasks keyed by task name.
+func (r *RunResult) Warnings() map[string][]*AssertionError {
+	out := make(map[string][]*AssertionError)
+	for _, t := range r.Tasks {
+		if len(t.Warnings) > 0 {
+			out[t.Name] = t.Warnings
+		}
+	}
+	return out
+}
+
+// Count returns the number of tasks with the given status.
+func (r *RunResult) Count(status TaskStatus) int {
+	n := 0
+	for _, t := range r.Tasks {
+		if t.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// CacheCount returns the number of tasks with the given cache status.
+func (r *RunResult) CacheCount(status CacheStatus) int {
+	n := 0
+	for _, t := range r.Tasks {
+		if t.Cache == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Durations returns the durations of the tasks that succeeded, in this run or a
+// resumed one, keyed by task name.
+// They can seed Workflow.Durations for critical-path scheduling of the next run.
+func (r *RunResult) Durations() map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	for _, t := range r.Tasks {
+		if t.Status == TaskStatusSucceeded || t.Status == TaskStatusSkipped {
+			out[t.Name] = t.Duration
+		}
+	}
+	return out
+}
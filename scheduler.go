@@ -1,7 +1,9 @@
 package iapetus
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,152 +11,468 @@ import (
 	"go.uber.org/zap"
 )
 
-// schedulerEvent represents an event in the scheduler loop.
-type schedulerEvent struct {
-	eventType string // e.g. "ready", "done", "cancel"
-	name      string // task name, if relevant
-}
-
 // dagScheduler encapsulates all state for parallel DAG execution.
+//
+// The scheduler is event-driven: run owns the dependency counters and starts a
+// task as soon as its last dependency finishes, and every task goroutine reports
+// its completion on doneCh exactly once. Nothing polls. Tasks are addressed by
+// their index in order so the hot path does no map lookups.
+//
+// Tasks whose dependencies are done wait in a ready queue and start while fewer
+// than Workflow.MaxParallel tasks are running, highest rank first (see less).
+//
+// Generators grow the graph while it runs: a generator's worker builds the
+// spawned tasks and registers them in the DAG (under the DAG's lock), and run
+// appends them to the scheduler state before completing the generator (see
+// expand). Workers get their task passed in and never read the growing slices.
 type dagScheduler struct {
 	w          *Workflow
-	taskMap    map[string]*Task
-	depCount   map[string]int
-	dependents map[string][]string
+	dag        *DAG // the workflow DAG, extended by generators; nil in unit tests
+	order      []*Task
+	index      map[string]int  // task name to index in order; owned by run
+	depCount   []int           // unfinished dependencies per task; owned by run
+	dependents [][]int         // indices of the tasks depending on each task
+	finished   []bool          // tasks whose dependents were released; owned by run
+	seq        []int           // position of each task in Workflow.Steps
+	paths      []time.Duration // critical path per task; nil unless Workflow.CriticalPath
+	ready      readyQueue
+	running    int               // started tasks that have not signalled yet; owned by run
+	resumed    map[int]TaskState // tasks completed by a previous run; see resume
 	wg         sync.WaitGroup
 	mu         sync.Mutex
 	errOnce    error
 	ctx        context.Context
 	cancel     context.CancelFunc
-	doneCh     chan struct{}
-	completed  map[string]bool
-	started    map[string]bool
-	cancelled  bool
-	eventCh    chan schedulerEvent
+	doneCh     chan int // completion signals, one slot per initial task; workers may wait for run once generators add tasks
+	results    map[string]*TaskResult
+	spawned    map[int][]*Task // tasks built by finished generators, not yet expanded; guarded by mu
 }
 
 // newDagScheduler initializes the scheduler state from the task order.
 func newDagScheduler(w *Workflow, order []*Task) *dagScheduler {
-	taskMap := make(map[string]*Task)
-	depCount := make(map[string]int)
-	dependents := make(map[string][]string)
-	for _, t := range order {
-		taskMap[t.Name] = t
-		depCount[t.Name] = len(t.Depends)
+	index := make(map[string]int, len(order))
+	for i, t := range order {
+		if t.backends == nil {
+			t.backends = w.backends
+		}
+		index[t.Name] = i
+	}
+	depCount := make([]int, len(order))
+	dependents := make([][]int, len(order))
+	for i, t := range order {
 		for _, dep := range t.Depends {
-			dependents[dep] = append(dependents[dep], t.Name)
+			j, ok := index[dep]
+			if !ok {
+				continue
+			}
+			depCount[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	steps := make(map[string]int, len(w.Steps))
+	for i := range w.Steps {
+		steps[w.Steps[i].Name] = i
+	}
+	seq := make([]int, len(order))
+	for i, t := range order {
+		seq[i] = i
+		if pos, ok := steps[t.Name]; ok {
+			seq[i] = pos
 		}
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	return &dagScheduler{
+	ctx, cancel := context.WithCancel(w.context())
+	s := &dagScheduler{
 		w:          w,
-		taskMap:    taskMap,
+		order:      order,
+		index:      index,
 		depCount:   depCount,
 		dependents: dependents,
-		errOnce:    nil,
+		finished:   make([]bool, len(order)),
+		seq:        seq,
 		ctx:        ctx,
 		cancel:     cancel,
-		doneCh:     make(chan struct{}, 1),
-		completed:  make(map[string]bool),
-		started:    make(map[string]bool),
-		cancelled:  false,
-		eventCh:    make(chan schedulerEvent, len(order)*2),
+		doneCh:     make(chan int, len(order)),
+		results:    make(map[string]*TaskResult, len(order)),
+		spawned:    make(map[int][]*Task),
+	}
+	if w.CriticalPath {
+		s.paths = criticalPaths(order, dependents, w.Durations)
 	}
+	s.ready = readyQueue{items: make([]int, 0, len(order)), less: s.less}
+	return s
 }
 
-// run executes the DAG in parallel, respecting dependencies.
-func (s *dagScheduler) run() error {
-	defer s.cancel()
-	// Seed event queue with tasks that have no dependencies
-	for name, count := range s.depCount {
-		if count == 0 {
-			s.eventCh <- schedulerEvent{eventType: "ready", name: name}
+// criticalPaths returns, for each task, the total duration of the longest chain
+// of tasks starting at it. order must be topological. Tasks without a known
+// duration weigh the mean of the known ones, or one unit if none are known.
+func criticalPaths(order []*Task, dependents [][]int, durations map[string]time.Duration) []time.Duration {
+	unit := time.Duration(1)
+	var total time.Duration
+	known := 0
+	for _, t := range order {
+		if d, ok := durations[t.Name]; ok {
+			total += d
+			known++
+		}
+	}
+	if known > 0 && total > 0 {
+		unit = total / time.Duration(known)
+	}
+	paths := make([]time.Duration, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		var longest time.Duration
+		for _, dep := range dependents[i] {
+			if paths[dep] > longest {
+				longest = paths[dep]
+			}
 		}
+		d, ok := durations[order[i].Name]
+		if !ok {
+			d = unit
+		}
+		paths[i] = d + longest
+	}
+	return paths
+}
+
+// less reports whether task a should start before task b: higher Priority first,
+// then the longer critical path (if enabled), then step order. Generated tasks
+// rank like their generator, in creation order.
+func (s *dagScheduler) less(a, b int) bool {
+	if pa, pb := s.order[a].Priority, s.order[b].Priority; pa != pb {
+		return pa > pb
+	}
+	if s.paths != nil && s.paths[a] != s.paths[b] {
+		return s.paths[a] > s.paths[b]
+	}
+	if s.seq[a] != s.seq[b] {
+		return s.seq[a] < s.seq[b]
 	}
-	if len(s.taskMap) == 0 {
+	return a < b
+}
+
+// readyQueue is a heap of task indices whose dependencies are done.
+type readyQueue struct {
+	items []int
+	less  func(a, b int) bool
+}
+
+func (q *readyQueue) Len() int           { return len(q.items) }
+func (q *readyQueue) Less(i, j int) bool { return q.less(q.items[i], q.items[j]) }
+func (q *readyQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *readyQueue) Push(x interface{}) { q.items = append(q.items, x.(int)) }
+func (q *readyQueue) Pop() interface{} {
+	n := len(q.items) - 1
+	x := q.items[n]
+	q.items = q.items[:n]
+	return x
+}
+
+// run executes the DAG in parallel, respecting dependencies. It returns after
+// every task has completed, or after the first failure once in-flight tasks finish.
+func (s *dagScheduler) run() error {
+	defer s.cancel()
+	// Let in-flight tasks finish (and fire their hooks) before returning
+	defer s.wg.Wait()
+	if len(s.order) == 0 {
 		s.w.logger.Debug("Scheduler: no tasks to run, exiting immediately", zap.String("workflow", s.w.Name))
 		return nil
 	}
 
-	for {
+	for i, count := range s.depCount {
+		if count == 0 {
+			heap.Push(&s.ready, i)
+		}
+	}
+	s.dispatch()
+	for s.running > 0 {
 		select {
 		case <-s.ctx.Done():
-			s.cancelled = true
-			s.w.logger.Debug("Scheduler: context cancelled, breaking main loop", zap.String("workflow", s.w.Name))
-			return s.errOnce
-
-		case <-s.doneCh:
-			s.w.logger.Debug("Scheduler: doneCh signaled, breaking main loop", zap.String("workflow", s.w.Name))
-			return s.errOnce
-
-		case ev := <-s.eventCh:
-			switch ev.eventType {
-			case "ready":
-				s.handleReady(ev.name)
-			case "done":
-				s.w.logger.Debug("All tasks completed, breaking scheduler loop", zap.String("workflow", s.w.Name))
-				return s.errOnce
-			case "cancel":
-				s.cancelled = true
-				s.w.logger.Debug("Scheduler: cancel event, breaking main loop", zap.String("workflow", s.w.Name))
-				return s.errOnce
-			}
-		case <-time.After(10 * time.Millisecond):
-			s.mu.Lock()
-			allCompleted := len(s.completed) == len(s.taskMap)
-			s.mu.Unlock()
-			if allCompleted {
-				s.eventCh <- schedulerEvent{eventType: "done"}
+			s.w.logger.Debug("Scheduler: context cancelled, stopping", zap.String("workflow", s.w.Name))
+			return s.err()
+		case i := <-s.doneCh:
+			s.running--
+			// A failed task cancels the context before signalling, so no
+			// dependents are started after the first failure.
+			if s.ctx.Err() != nil {
+				s.w.logger.Debug("Scheduler: task failed, stopping", zap.String("workflow", s.w.Name))
+				return s.err()
 			}
+			s.expand(i)
+			s.complete(i)
+			s.dispatch()
 		}
 	}
+	s.w.logger.Debug("All tasks completed", zap.String("workflow", s.w.Name))
+	return s.err()
 }
 
-// handleReady handles a ready event by starting the task if not already started.
-func (s *dagScheduler) handleReady(name string) {
+// err returns the first task error, if any.
+func (s *dagScheduler) err() error {
 	s.mu.Lock()
-	task, ok := s.taskMap[name]
-	if !ok || s.started[name] {
-		s.mu.Unlock()
+	defer s.mu.Unlock()
+	return s.errOnce
+}
+
+// complete queues the dependents of task i whose dependencies are now all done.
+func (s *dagScheduler) complete(i int) {
+	s.finished[i] = true
+	for _, dep := range s.dependents[i] {
+		s.depCount[dep]--
+		if s.depCount[dep] == 0 {
+			heap.Push(&s.ready, dep)
+		}
+	}
+}
+
+// resume marks tasks that succeeded in a previous run; they are completed from
+// their saved state instead of running. Generators always run again, as the
+// tasks they spawn are not steps of the workflow and cannot be resumed.
+func (s *dagScheduler) resume(done map[string]TaskState) {
+	if len(done) == 0 {
 		return
 	}
-	s.started[name] = true
+	s.resumed = make(map[int]TaskState, len(done))
+	for i, t := range s.order {
+		if st, ok := done[t.Name]; ok && t.Generate == nil {
+			s.resumed[i] = st
+		}
+	}
+}
+
+// spawn builds the tasks of a generator that succeeded and registers them in the
+// DAG, including the fan-in edges. run adds them to the schedule in expand.
+func (s *dagScheduler) spawn(i int, gen *Task) error {
+	tasks, err := s.w.generate(gen)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(tasks))
+	for k, t := range tasks {
+		names[k] = t.Name
+		if s.dag == nil {
+			continue
+		}
+		if err := s.dag.AddTask(t); err != nil {
+			return fmt.Errorf("generator %s: %w", gen.Name, err)
+		}
+		for _, fanIn := range gen.Generate.FanIn {
+			if err := s.dag.AddDependency(fanIn, t.Name); err != nil {
+				return fmt.Errorf("generator %s: %w", gen.Name, err)
+			}
+		}
+	}
+	s.w.logger.Info("Generated tasks", zap.String("task", gen.Name), zap.Int("count", len(tasks)))
+	s.mu.Lock()
+	s.spawned[i] = tasks
+	if s.w.generated == nil {
+		s.w.generated = make(map[string][]string)
+	}
+	s.w.generated[gen.Name] = names
 	s.mu.Unlock()
-	s.wg.Add(1)
-	go s.runTask(name, task)
+	return nil
 }
 
-// runTask executes a single task and handles completion, dependents, and error propagation.
-func (s *dagScheduler) runTask(name string, task *Task) {
+// expand adds the tasks spawned by generator i to the schedule. Each depends on
+// the generator and its other unfinished dependencies, and every fan-in task of
+// the generator gains a dependency on each of them. It must run before complete(i).
+func (s *dagScheduler) expand(i int) {
+	s.mu.Lock()
+	tasks := s.spawned[i]
+	delete(s.spawned, i)
+	s.mu.Unlock()
+	if len(tasks) == 0 {
+		return
+	}
+	var fanIn []int
+	for _, name := range s.order[i].Generate.FanIn {
+		if j, ok := s.index[name]; ok {
+			fanIn = append(fanIn, j)
+		}
+	}
+	for _, t := range tasks {
+		n := len(s.order)
+		s.order = append(s.order, t)
+		s.index[t.Name] = n
+		s.dependents = append(s.dependents, nil)
+		count := 0
+		for _, dep := range t.Depends {
+			j, ok := s.index[dep]
+			if !ok || s.finished[j] {
+				continue
+			}
+			count++
+			s.dependents[j] = append(s.dependents[j], n)
+		}
+		s.depCount = append(s.depCount, count)
+		s.finished = append(s.finished, false)
+		s.seq = append(s.seq, s.seq[i])
+		if s.paths != nil {
+			s.paths = append(s.paths, s.paths[i])
+		}
+		for _, j := range fanIn {
+			s.depCount[j]++
+			s.dependents[n] = append(s.dependents[n], j)
+		}
+	}
+}
+
+// dispatch starts ready tasks in rank order while capacity allows. Resumed
+// tasks complete immediately without taking capacity.
+func (s *dagScheduler) dispatch() {
+	for s.ready.Len() > 0 && (s.w.MaxParallel <= 0 || s.running < s.w.MaxParallel) {
+		i := heap.Pop(&s.ready).(int)
+		if st, ok := s.resumed[i]; ok {
+			task := s.order[i]
+			st.restore(task)
+			s.mu.Lock()
+			s.results[task.Name] = &TaskResult{Name: task.Name, Status: TaskStatusSkipped, Duration: st.Duration}
+			s.mu.Unlock()
+			s.w.logger.Debug("Task succeeded in resumed run, skipping", zap.String("task", task.Name))
+			s.complete(i)
+			continue
+		}
+		s.running++
+		s.wg.Add(1)
+		go s.runTask(i, s.order[i])
+	}
+}
+
+// recordResult stores the outcome of a finished task. Callers must hold s.mu.
+func (s *dagScheduler) recordResult(name string, task *Task, err error, d time.Duration, cache CacheStatus) {
+	res := &TaskResult{
+		Name:     name,
+		Status:   TaskStatusSucceeded,
+		Duration: d,
+		Err:      err,
+		Warnings: task.Actual.Warnings,
+		Cache:    cache,
+		Workflow: task.Actual.Workflow,
+	}
+	if err != nil {
+		res.Status = TaskStatusFailed
+		if cause := context.Cause(s.w.context()); cause != nil && errors.Is(err, cause) {
+			res.Status = interruptedStatus(cause)
+		}
+	}
+	for _, w := range res.Warnings {
+		s.w.logger.Warn("Assertion warning", zap.String("task", name), zap.String("warning", w.Error()))
+	}
+	res.GeneratedBy = task.generatedBy
+	s.results[name] = res
+	s.w.saveTaskState(task, res)
+}
+
+// interruptedStatus is the status of tasks that did not finish because the
+// workflow's context was done with cause.
+func interruptedStatus(cause error) TaskStatus {
+	var timeout *WorkflowTimeoutError
+	if errors.As(cause, &timeout) {
+		return TaskStatusTimedOut
+	}
+	return TaskStatusCancelled
+}
+
+// interrupted handles a run whose workflow context is done. It marks the tasks
+// that did not finish TaskStatusTimedOut (if the workflow's Timeout expired) or
+// TaskStatusCancelled, and returns a *WorkflowTimeoutError or *WorkflowCancelledError
+// listing them, unless err is a task failure that is not due to the interruption.
+// Callers must hold s.mu.
+func (s *dagScheduler) interrupted(err error) error {
+	cause := context.Cause(s.w.context())
+	status := interruptedStatus(cause)
+	var unfinished []string
+	for _, t := range s.order {
+		res, ok := s.results[t.Name]
+		if !ok {
+			res = &TaskResult{Name: t.Name, Status: status, GeneratedBy: t.generatedBy}
+			s.results[t.Name] = res
+		}
+		if res.Status == status {
+			unfinished = append(unfinished, t.Name)
+		}
+	}
+	if err != nil && !errors.Is(err, cause) {
+		return err
+	}
+	var timeout *WorkflowTimeoutError
+	if errors.As(cause, &timeout) {
+		e := WorkflowTimeoutError{WorkflowName: timeout.WorkflowName, Timeout: timeout.Timeout, Unfinished: unfinished, expired: timeout}
+		if e.WorkflowName == "" {
+			e.WorkflowName = s.w.Name
+		}
+		s.w.logger.Error("Workflow timed out", zap.String("workflow", s.w.Name), zap.Duration("timeout", e.Timeout), zap.Strings("unfinished", unfinished))
+		return &e
+	}
+	s.w.logger.Error("Workflow cancelled", zap.String("workflow", s.w.Name), zap.Error(cause), zap.Strings("unfinished", unfinished))
+	return &WorkflowCancelledError{WorkflowName: s.w.Name, Cause: cause, Unfinished: unfinished}
+}
+
+// runAlways runs the AlwaysRun tasks that did not start because the run stopped
+// early, one at a time in dependency order. They run with a context that the
+// workflow's cancellation or timeout does not cancel. Their outcome is recorded,
+// but a failure does not replace the error that stopped the run.
+func (s *dagScheduler) runAlways() {
+	ctx := context.WithoutCancel(s.w.context())
+	for _, task := range s.order {
+		if !task.AlwaysRun {
+			continue
+		}
+		s.mu.Lock()
+		_, done := s.results[task.Name]
+		s.mu.Unlock()
+		if done {
+			continue
+		}
+		s.w.logger.Info("Running always-run task after the run stopped", zap.String("task", task.Name))
+		start := time.Now()
+		s.w.OnTaskStart(task)
+		cache, err := func() (cache CacheStatus, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic in task %s: %v", task.Name, r)
+				}
+			}()
+			return s.w.runCached(ctx, task)
+		}()
+		s.mu.Lock()
+		s.recordResult(task.Name, task, err, time.Since(start), cache)
+		if err != nil {
+			s.w.OnTaskFailure(task, err)
+		} else {
+			s.w.OnTaskSuccess(task)
+		}
+		s.mu.Unlock()
+		s.w.OnTaskComplete(task)
+	}
+}
+
+// runTask executes a single task and signals its completion to run. A generator
+// also builds the tasks it spawns; failing to do so fails the generator.
+func (s *dagScheduler) runTask(i int, task *Task) {
 	defer s.wg.Done()
+	start := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
-			err := fmt.Errorf("panic in task %s: %v", name, r)
-			s.mu.Lock()
-			s.w.OnTaskFailure(task, err)
-			if s.errOnce == nil {
-				s.errOnce = &WorkflowError{
-					StepName:     task.Name,
-					WorkflowName: s.w.Name,
-					Err:          err,
-				}
-				s.cancel()
-			}
-			s.completed[name] = true
-			if len(s.completed) == len(s.taskMap) {
-				select {
-				case s.doneCh <- struct{}{}:
-				default:
-				}
-			}
-			s.mu.Unlock()
-			s.w.OnTaskComplete(task)
+			s.finish(i, task, fmt.Errorf("panic in task %s: %v", task.Name, r), start, "")
 			s.w.logger.Debug("Task completed (panic)", zap.String("task", task.Name))
 		}
 	}()
 	s.w.OnTaskStart(task)
-	err := task.Run()
+	cache, err := s.w.runCached(s.w.context(), task)
+	if err == nil && task.Generate != nil {
+		err = s.spawn(i, task)
+	}
+	s.finish(i, task, err, start, cache)
+	s.w.logger.Debug("Task completed", zap.String("task", task.Name))
+}
+
+// finish records the task outcome, fires the hooks and signals completion.
+// On failure the scheduler context is cancelled before signalling. Once the
+// context is cancelled run no longer reads doneCh, so the signal is dropped.
+func (s *dagScheduler) finish(i int, task *Task, err error, start time.Time, cache CacheStatus) {
 	s.mu.Lock()
+	s.recordResult(task.Name, task, err, time.Since(start), cache)
 	if err != nil {
 		s.w.OnTaskFailure(task, err)
 		if s.errOnce == nil {
@@ -168,23 +486,10 @@ func (s *dagScheduler) runTask(name string, task *Task) {
 	} else {
 		s.w.OnTaskSuccess(task)
 	}
-	s.completed[name] = true
-	if len(s.completed) == len(s.taskMap) {
-		select {
-		case s.doneCh <- struct{}{}:
-		default:
-		}
-	}
 	s.mu.Unlock()
 	s.w.OnTaskComplete(task)
-	s.w.logger.Debug("Task completed", zap.String("task", task.Name))
-
-	s.mu.Lock()
-	for _, dep := range s.dependents[name] {
-		s.depCount[dep]--
-		if s.depCount[dep] == 0 && !s.started[dep] {
-			s.eventCh <- schedulerEvent{eventType: "ready", name: dep}
-		}
+	select {
+	case s.doneCh <- i:
+	case <-s.ctx.Done():
 	}
-	s.mu.Unlock()
 }
@@ -0,0 +1,99 @@
+package iapetus
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// benchSteps builds n tasks where task i depends on the tasks returned by deps(i).
+// Tasks run on the in-process test bash backend, so the benchmarks measure
+// scheduling overhead rather than process startup.
+func benchSteps(n int, deps func(i int) []int) []Task {
+	steps := make([]Task, n)
+	for i := range steps {
+		var depends []string
+		for _, d := range deps(i) {
+			depends = append(depends, fmt.Sprintf("t%d", d))
+		}
+		steps[i] = Task{
+			Name:    fmt.Sprintf("t%d", i),
+			Command: "true",
+			Depends: depends,
+			Asserts: []func(*Task) error{AssertExitCode(0)},
+		}
+	}
+	return steps
+}
+
+// runBenchmark runs a workflow over fresh copies of steps b.N times and reports
+// task throughput and wall-clock time per task. For chains, ns/task is the
+// latency from a task finishing to its dependent finishing.
+func runBenchmark(b *testing.B, steps []Task) {
+	b.ReportAllocs()
+	var elapsed time.Duration
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		wf := Workflow{Name: "bench", Steps: append([]Task(nil), steps...), logger: zap.NewNop()}
+		b.StartTimer()
+		start := time.Now()
+		if err := wf.Run(); err != nil {
+			b.Fatalf("workflow failed: %v", err)
+		}
+		elapsed += time.Since(start)
+	}
+	total := float64(len(steps) * b.N)
+	b.ReportMetric(total/elapsed.Seconds(), "tasks/s")
+	b.ReportMetric(float64(elapsed.Nanoseconds())/total, "ns/task")
+}
+
+func noDeps(int) []int { return nil }
+
+func chain(i int) []int {
+	if i == 0 {
+		return nil
+	}
+	return []int{i - 1}
+}
+
+// layered returns deps for layers of the given width, each task depending on
+// every task of the previous layer.
+func layered(width int) func(i int) []int {
+	return func(i int) []int {
+		layer := i / width
+		if layer == 0 {
+			return nil
+		}
+		deps := make([]int, width)
+		for j := range deps {
+			deps[j] = (layer-1)*width + j
+		}
+		return deps
+	}
+}
+
+func BenchmarkScheduler_Wide(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("tasks=%d", n), func(b *testing.B) {
+			runBenchmark(b, benchSteps(n, noDeps))
+		})
+	}
+}
+
+func BenchmarkScheduler_Deep(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("tasks=%d", n), func(b *testing.B) {
+			runBenchmark(b, benchSteps(n, chain))
+		})
+	}
+}
+
+func BenchmarkScheduler_Layered(b *testing.B) {
+	for _, width := range []int{10, 100} {
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			runBenchmark(b, benchSteps(10*width, layered(width)))
+		})
+	}
+}
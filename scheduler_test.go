@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,7 +15,9 @@ import (
 )
 
 func init() {
-	RegisterBackend("bash", &testBashBackend{})
+	// Replace the real bash backend so scheduler tests don't fork processes.
+	UnregisterBackend("bash")
+	MustRegisterBackend("bash", &testBashBackend{})
 }
 
 type testBashBackend struct{}
@@ -272,7 +275,9 @@ func TestDagScheduler_ObservabilityHooks(t *testing.T) {
 	w.AddOnTaskSuccessHook(func(task *Task) { mu.Lock(); calls[task.Name]["success"] = true; mu.Unlock() })
 	w.AddOnTaskFailureHook(func(task *Task, err error) { mu.Lock(); calls[task.Name]["fail"] = true; mu.Unlock() })
 	w.AddOnTaskCompleteHook(func(task *Task) { mu.Lock(); calls[task.Name]["complete"] = true; mu.Unlock() })
-	RegisterBackend("bash", &BashBackend{})
+	if err := w.RegisterBackend("bash", &BashBackend{}); err != nil {
+		t.Fatalf("failed to register workflow backend: %v", err)
+	}
 	tasks := []*Task{
 		{
 			Name:    "ok",
@@ -354,3 +359,80 @@ func TestDagScheduler_NoGoroutineLeak(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+// runStartOrder runs tasks in a workflow and returns the order in which they started.
+func runStartOrder(t *testing.T, w *Workflow, tasks ...*Task) []string {
+	t.Helper()
+	var mu sync.Mutex
+	var started []string
+	w.AddOnTaskStartHook(func(task *Task) { mu.Lock(); started = append(started, task.Name); mu.Unlock() })
+	for _, task := range tasks {
+		w.AddTask(*task)
+	}
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return started
+}
+
+func TestDagScheduler_Priority(t *testing.T) {
+	w := NewWorkflow("priority", zap.NewNop()).SetMaxParallel(1)
+	got := runStartOrder(t, w,
+		NewTask("low", 0, zap.NewNop()).AddCommand("true"),
+		NewTask("high", 0, zap.NewNop()).AddCommand("true").SetPriority(5),
+		NewTask("mid", 0, zap.NewNop()).AddCommand("true").SetPriority(1),
+		NewTask("low2", 0, zap.NewNop()).AddCommand("true"),
+	)
+	assert.Equal(t, []string{"high", "mid", "low", "low2"}, got)
+}
+
+func TestDagScheduler_CriticalPath(t *testing.T) {
+	tasks := func() []*Task {
+		chain := []*Task{NewTask("unit", 0, zap.NewNop()).AddCommand("true")}
+		for i, dep := range []string{"", "it1", "it2"} {
+			task := NewTask(fmt.Sprintf("it%d", i+1), 0, zap.NewNop()).AddCommand("true")
+			if dep != "" {
+				task.Depends = []string{dep}
+			}
+			chain = append(chain, task)
+		}
+		return chain
+	}
+
+	w := NewWorkflow("step-order", zap.NewNop()).SetMaxParallel(1)
+	assert.Equal(t, []string{"unit", "it1", "it2", "it3"}, runStartOrder(t, w, tasks()...))
+
+	w = NewWorkflow("critical-path", zap.NewNop()).SetMaxParallel(1).SetCriticalPath(nil)
+	assert.Equal(t, []string{"it1", "it2", "unit", "it3"}, runStartOrder(t, w, tasks()...))
+
+	// With history, a slow single task outweighs a chain of fast ones.
+	durations := map[string]time.Duration{"unit": time.Minute, "it1": time.Second, "it2": time.Second, "it3": time.Second}
+	w = NewWorkflow("history", zap.NewNop()).SetMaxParallel(1).SetCriticalPath(durations)
+	assert.Equal(t, []string{"unit", "it1", "it2", "it3"}, runStartOrder(t, w, tasks()...))
+}
+
+func TestDagScheduler_MaxParallel(t *testing.T) {
+	var running, peak atomic.Int32
+	w := NewWorkflow("max-parallel", zap.NewNop()).SetMaxParallel(2)
+	for i := 0; i < 8; i++ {
+		w.AddTask(*NewTask(fmt.Sprintf("t%d", i), 0, zap.NewNop()).SetFunc(func(ctx context.Context, task *Task) (Output, error) {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return Output{}, nil
+		}))
+	}
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("expected at most 2 concurrent tasks and some parallelism, got peak %d", got)
+	}
+	assert.Equal(t, 8, w.Result().Count(TaskStatusSucceeded))
+}
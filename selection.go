@@ -0,0 +1,159 @@
+package iapetus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selection restricts a run to a subset of the workflow's tasks. Each non-empty
+// field narrows the selection further, so e.g. Targets and Tags together select
+// the target closure's tasks that carry one of the tags.
+//
+// A selected task whose dependency is pruned runs without waiting for it, as if
+// the dependency had already succeeded.
+type Selection struct {
+	// Targets selects these tasks and their transitive dependencies.
+	Targets []string
+	// From selects these tasks and every task that transitively depends on them.
+	From []string
+	// Tags selects only tasks with at least one of these tags.
+	Tags []string
+	// SkipTags prunes tasks with any of these tags.
+	SkipTags []string
+}
+
+// IsEmpty reports whether the selection selects every task.
+func (s Selection) IsEmpty() bool {
+	return len(s.Targets) == 0 && len(s.From) == 0 && len(s.Tags) == 0 && len(s.SkipTags) == 0
+}
+
+// PlannedTask is one task of a Plan.
+type PlannedTask struct {
+	Name string
+	// Pruned is true if the selection excludes the task; Reason says why.
+	Pruned bool
+	Reason string
+}
+
+// Plan lists the tasks of a workflow in step order and whether each will run.
+type Plan struct {
+	Tasks []PlannedTask
+}
+
+// Selected returns the names of the tasks that will run.
+func (p *Plan) Selected() []string {
+	var names []string
+	for _, t := range p.Tasks {
+		if !t.Pruned {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// Pruned returns the tasks excluded by the selection.
+func (p *Plan) Pruned() []PlannedTask {
+	var pruned []PlannedTask
+	for _, t := range p.Tasks {
+		if t.Pruned {
+			pruned = append(pruned, t)
+		}
+	}
+	return pruned
+}
+
+// Plan applies the workflow's Selection without running anything.
+func (w *Workflow) Plan() (*Plan, error) {
+	dag := NewDag()
+	for i := range w.Steps {
+		if err := dag.AddTask(&w.Steps[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := dag.Validate(); err != nil {
+		return nil, err
+	}
+	pruned, err := w.Selection.prune(dag, w.Steps)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	for i := range w.Steps {
+		name := w.Steps[i].Name
+		reason, ok := pruned[name]
+		plan.Tasks = append(plan.Tasks, PlannedTask{Name: name, Pruned: ok, Reason: reason})
+	}
+	return plan, nil
+}
+
+// prune returns the tasks the selection excludes, with the reason for each.
+// Each task gets the reason of the first filter that excludes it.
+func (s Selection) prune(dag *DAG, steps []Task) (map[string]string, error) {
+	pruned := make(map[string]string)
+	if s.IsEmpty() {
+		return pruned, nil
+	}
+	keep := func(names []string, closure func(string) ([]string, bool), reason string) error {
+		if len(names) == 0 {
+			return nil
+		}
+		selected := make(map[string]bool)
+		for _, name := range names {
+			related, ok := closure(name)
+			if !ok {
+				return fmt.Errorf("unknown task %q", name)
+			}
+			selected[name] = true
+			for _, r := range related {
+				selected[r] = true
+			}
+		}
+		for i := range steps {
+			name := steps[i].Name
+			if _, done := pruned[name]; !done && !selected[name] {
+				pruned[name] = fmt.Sprintf(reason, strings.Join(names, ", "))
+			}
+		}
+		return nil
+	}
+	if err := keep(s.Targets, dag.GetAllDependencies, "not needed by target %s"); err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	if err := keep(s.From, dag.GetAllDependents, "not downstream of %s"); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	for i := range steps {
+		task := &steps[i]
+		if _, done := pruned[task.Name]; done {
+			continue
+		}
+		if len(s.Tags) > 0 && !task.HasAnyTag(s.Tags...) {
+			pruned[task.Name] = fmt.Sprintf("no tag in %s", strings.Join(s.Tags, ", "))
+		} else if skip := matchingTags(task, s.SkipTags); len(skip) > 0 {
+			pruned[task.Name] = fmt.Sprintf("skipped by tag %s", strings.Join(skip, ", "))
+		}
+	}
+	if len(pruned) == len(steps) {
+		return nil, fmt.Errorf("selection matches no tasks")
+	}
+	return pruned, nil
+}
+
+// HasAnyTag reports whether the task has at least one of tags.
+func (t *Task) HasAnyTag(tags ...string) bool {
+	return len(matchingTags(t, tags)) > 0
+}
+
+// matchingTags returns the tags of t that appear in tags.
+func matchingTags(t *Task, tags []string) []string {
+	var out []string
+	for _, tag := range t.Tags {
+		for _, want := range tags {
+			if tag == want {
+				out = append(out, tag)
+				break
+			}
+		}
+	}
+	return out
+}
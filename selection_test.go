@@ -0,0 +1,109 @@
+package iapetus
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// selectionWorkflow builds:
+//
+//	fetch -> build -> deploy-a -> smoke
+//	cluster ------\-> deploy-b
+func selectionWorkflow() *Workflow {
+	w := NewWorkflow("select", zap.NewNop())
+	add := func(name string, tags []string, depends ...string) {
+		task := NewTask(name, 0, zap.NewNop()).AddCommand("true").AddTags(tags...)
+		task.Depends = depends
+		w.AddTask(*task)
+	}
+	add("fetch", nil)
+	add("cluster", []string{"slow"})
+	add("build", nil, "fetch")
+	add("deploy-a", nil, "build")
+	add("deploy-b", nil, "build", "cluster")
+	add("smoke", []string{"smoke"}, "deploy-a")
+	return w
+}
+
+func TestWorkflow_Plan(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection Selection
+		selected  []string
+	}{
+		{"all", Selection{}, []string{"fetch", "cluster", "build", "deploy-a", "deploy-b", "smoke"}},
+		{"target", Selection{Targets: []string{"deploy-b"}}, []string{"fetch", "cluster", "build", "deploy-b"}},
+		{"from", Selection{From: []string{"build"}}, []string{"build", "deploy-a", "deploy-b", "smoke"}},
+		{"from and target", Selection{From: []string{"build"}, Targets: []string{"deploy-b"}}, []string{"build", "deploy-b"}},
+		{"tags", Selection{Tags: []string{"smoke", "slow"}}, []string{"cluster", "smoke"}},
+		{"skip tags", Selection{Targets: []string{"deploy-b"}, SkipTags: []string{"slow"}}, []string{"fetch", "build", "deploy-b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := selectionWorkflow()
+			w.Selection = tt.selection
+			plan, err := w.Plan()
+			if err != nil {
+				t.Fatalf("Plan failed: %v", err)
+			}
+			assert.Equal(t, tt.selected, plan.Selected())
+			for _, p := range plan.Pruned() {
+				if p.Reason == "" {
+					t.Errorf("expected a reason for pruned task %s", p.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkflow_PlanReasonsAndErrors(t *testing.T) {
+	w := selectionWorkflow()
+	w.Selection = Selection{Targets: []string{"deploy-b"}, SkipTags: []string{"slow"}}
+	plan, err := w.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	reasons := map[string]string{}
+	for _, p := range plan.Pruned() {
+		reasons[p.Name] = p.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"cluster":  "skipped by tag slow",
+		"deploy-a": "not needed by target deploy-b",
+		"smoke":    "not needed by target deploy-b",
+	}, reasons)
+
+	w.Selection = Selection{Targets: []string{"nope"}}
+	if _, err := w.Plan(); err == nil || !strings.Contains(err.Error(), `unknown task "nope"`) {
+		t.Errorf("expected unknown target error, got %v", err)
+	}
+	w.Selection = Selection{Tags: []string{"none"}}
+	if _, err := w.Plan(); err == nil || !strings.Contains(err.Error(), "matches no tasks") {
+		t.Errorf("expected empty selection error, got %v", err)
+	}
+}
+
+func TestWorkflow_RunSelection(t *testing.T) {
+	w := selectionWorkflow()
+	w.Selection = Selection{From: []string{"deploy-a"}}
+	var mu sync.Mutex
+	var ran []string
+	w.AddOnTaskStartHook(func(task *Task) { mu.Lock(); ran = append(ran, task.Name); mu.Unlock() })
+	if err := w.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.ElementsMatch(t, []string{"deploy-a", "smoke"}, ran)
+	res := w.Result()
+	assert.Equal(t, 4, res.Count(TaskStatusPruned))
+	assert.Equal(t, TaskStatusSucceeded, res.Task("smoke").Status)
+
+	w = selectionWorkflow()
+	w.Selection = Selection{Targets: []string{"missing"}}
+	if err := w.Run(); err == nil || !strings.Contains(err.Error(), "step 'selection'") {
+		t.Errorf("expected selection error, got %v", err)
+	}
+}
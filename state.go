@@ -0,0 +1,179 @@
+package iapetus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStateDir is the directory, relative to the working directory, where the
+// CLI persists run state for --resume.
+const DefaultStateDir = ".iapetus/runs"
+
+// RunState is the persisted state of a workflow run, written to
+// <StateDir>/<RunID>.json after every task so a failed run can be resumed.
+type RunState struct {
+	RunID     string               `json:"run_id"`
+	Workflow  string               `json:"workflow"`
+	StartedAt time.Time            `json:"started_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Tasks     map[string]TaskState `json:"tasks"`
+}
+
+// TaskState is the persisted outcome and output of one task.
+type TaskState struct {
+	Status TaskStatus `json:"status"`
+	// Fingerprint identifies the task definition the outcome belongs to; see TaskFingerprint.
+	Fingerprint string            `json:"fingerprint"`
+	Duration    time.Duration     `json:"duration"`
+	Error       string            `json:"error,omitempty"`
+	ExitCode    int               `json:"exit_code"`
+	Output      string            `json:"output,omitempty"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+// TaskFingerprint returns a digest of the parts of a task that affect what it does:
+// command, args, environment, image, working dir, backend, HTTP request, sub-workflow
+// path and params, timeout, retries and dependencies. Assertions and Go values
+// (Func, SubWorkflow.Workflow) are not part of it.
+// Defaults applied by Task.Run are applied first, so the fingerprint is the same
+// before and after the task runs.
+func TaskFingerprint(t *Task) string {
+	timeout, retries, backend, env := t.Timeout, t.Retries, t.Backend, t.EnvMap
+	if timeout == 0 {
+		timeout = DefaultTaskTimeout
+	}
+	if retries == 0 {
+		retries = 1
+	}
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	if len(env) == 0 {
+		env = nil
+	}
+	def := struct {
+		Name       string            `json:"name"`
+		Command    string            `json:"command"`
+		Args       []string          `json:"args"`
+		EnvMap     map[string]string `json:"env_map"`
+		Image      string            `json:"image"`
+		WorkingDir string            `json:"working_dir"`
+		Backend    string            `json:"backend"`
+		HTTP       *HTTPRequest      `json:"http"`
+		Timeout    time.Duration     `json:"timeout"`
+		Retries    int               `json:"retries"`
+		Depends    []string          `json:"depends"`
+		Workflow   *SubWorkflow      `json:"workflow"`
+	}{t.Name, t.Command, t.Args, env, t.Image, t.WorkingDir, backend, t.HTTP, timeout, retries, t.Depends, t.Workflow}
+	data, _ := json.Marshal(def)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// runStatePath returns the state file of a run.
+func runStatePath(dir, runID string) string {
+	return filepath.Join(dir, runID+".json")
+}
+
+// LoadRunState reads the persisted state of a run from dir.
+func LoadRunState(dir, runID string) (*RunState, error) {
+	if runID == "" || filepath.Base(runID) != runID {
+		return nil, fmt.Errorf("invalid run id %q", runID)
+	}
+	data, err := os.ReadFile(runStatePath(dir, runID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state of run %s: %w", runID, err)
+	}
+	var st RunState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state of run %s: %w", runID, err)
+	}
+	if st.Tasks == nil {
+		st.Tasks = make(map[string]TaskState)
+	}
+	return &st, nil
+}
+
+// Save writes the state to dir, replacing any previous state of the run atomically.
+func (s *RunState) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state of run %s: %w", s.RunID, err)
+	}
+	tmp, err := os.CreateTemp(dir, s.RunID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write state of run %s: %w", s.RunID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state of run %s: %w", s.RunID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state of run %s: %w", s.RunID, err)
+	}
+	if err := os.Rename(tmp.Name(), runStatePath(dir, s.RunID)); err != nil {
+		return fmt.Errorf("failed to write state of run %s: %w", s.RunID, err)
+	}
+	return nil
+}
+
+// completedTasks checks that the workflow is compatible with the state and returns
+// the tasks that succeeded and can be skipped. A task that succeeded must not have
+// changed since (see TaskFingerprint); tasks that failed, never ran, or are new
+// are free to change and will run. Callers must have propagated workflow defaults.
+func (s *RunState) completedTasks(w *Workflow) (map[string]TaskState, error) {
+	if s.Workflow != w.Name {
+		return nil, fmt.Errorf("run %s belongs to workflow %q, not %q", s.RunID, s.Workflow, w.Name)
+	}
+	done := make(map[string]TaskState)
+	for i := range w.Steps {
+		task := &w.Steps[i]
+		st, ok := s.Tasks[task.Name]
+		if !ok || st.Status != TaskStatusSucceeded {
+			continue
+		}
+		if st.Fingerprint != TaskFingerprint(task) {
+			return nil, fmt.Errorf("task %s changed since run %s succeeded it; rerun without resuming", task.Name, s.RunID)
+		}
+		done[task.Name] = st
+	}
+	return done, nil
+}
+
+// restore sets the task's Actual output from its persisted state.
+func (st TaskState) restore(t *Task) {
+	t.Actual = Output{
+		ExitCode:   st.ExitCode,
+		Output:     st.Output,
+		StatusCode: st.StatusCode,
+		Env:        st.Env,
+		Duration:   st.Duration,
+	}
+}
+
+// newTaskState captures the outcome of a finished task.
+func newTaskState(t *Task, res *TaskResult) TaskState {
+	st := TaskState{
+		Status:      res.Status,
+		Fingerprint: TaskFingerprint(t),
+		Duration:    res.Duration,
+		ExitCode:    t.Actual.ExitCode,
+		Output:      t.Actual.Output,
+		StatusCode:  t.Actual.StatusCode,
+		Env:         t.Actual.Env,
+	}
+	if res.Err != nil {
+		st.Error = res.Err.Error()
+	}
+	return st
+}
@@ -0,0 +1,155 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTaskFingerprint(t *testing.T) {
+	a := Task{Name: "build", Command: "make", Args: []string{"all"}, Depends: []string{"fetch"}}
+	b := a
+	b.Asserts = []func(*Task) error{AssertExitCode(0)}
+	if TaskFingerprint(&a) != TaskFingerprint(&b) {
+		t.Errorf("expected assertions not to affect the fingerprint")
+	}
+	for name, change := range map[string]func(*Task){
+		"command": func(t *Task) { t.Command = "go" },
+		"args":    func(t *Task) { t.Args = []string{"test"} },
+		"env":     func(t *Task) { t.EnvMap = map[string]string{"CI": "1"} },
+		"depends": func(t *Task) { t.Depends = nil },
+		"backend": func(t *Task) { t.Backend = "docker" },
+	} {
+		c := a
+		change(&c)
+		if TaskFingerprint(&a) == TaskFingerprint(&c) {
+			t.Errorf("expected %s change to alter the fingerprint", name)
+		}
+	}
+}
+
+func TestRunState_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	st := &RunState{RunID: "run-1", Workflow: "wf", Tasks: map[string]TaskState{
+		"a": {Status: TaskStatusSucceeded, Output: "hi", Env: map[string]string{"V": "1"}},
+	}}
+	if err := st.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := LoadRunState(dir, "run-1")
+	if err != nil {
+		t.Fatalf("LoadRunState failed: %v", err)
+	}
+	if got.Workflow != "wf" || got.Tasks["a"].Output != "hi" || got.Tasks["a"].Env["V"] != "1" {
+		t.Errorf("unexpected state %+v", got)
+	}
+	if _, err := LoadRunState(dir, "missing"); err == nil {
+		t.Errorf("expected error for unknown run")
+	}
+	if _, err := LoadRunState(dir, "../run-1"); err == nil {
+		t.Errorf("expected error for invalid run id")
+	}
+}
+
+// resumeWorkflow builds a fetch -> build -> test workflow whose build step fails
+// while *broken is true. calls counts the runs of each step.
+func resumeWorkflow(stateDir string, calls map[string]int, broken *bool) *Workflow {
+	w := NewWorkflow("resume", zap.NewNop())
+	w.StateDir = stateDir
+	step := func(name string, depends ...string) Task {
+		task := NewTask(name, 0, zap.NewNop()).SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			calls[t.Name]++
+			if t.Name == "build" && *broken {
+				return Output{ExitCode: 2}, errors.New("compile error")
+			}
+			return Output{Output: t.Name + " ok"}, nil
+		})
+		task.Depends = depends
+		return *task
+	}
+	w.AddTask(step("fetch"))
+	w.AddTask(step("build", "fetch"))
+	w.AddTask(step("test", "build"))
+	return w
+}
+
+func TestWorkflow_Resume(t *testing.T) {
+	dir := t.TempDir()
+	calls := map[string]int{}
+	broken := true
+	w := resumeWorkflow(dir, calls, &broken)
+	if err := w.Run(); err == nil {
+		t.Fatalf("expected first run to fail")
+	}
+	st, err := LoadRunState(dir, w.RunID)
+	if err != nil {
+		t.Fatalf("LoadRunState failed: %v", err)
+	}
+	if st.Tasks["fetch"].Status != TaskStatusSucceeded || st.Tasks["build"].Status != TaskStatusFailed {
+		t.Fatalf("unexpected saved state %+v", st.Tasks)
+	}
+	if _, ok := st.Tasks["test"]; ok {
+		t.Errorf("expected test not to be saved before it ran")
+	}
+
+	broken = false
+	w = resumeWorkflow(dir, calls, &broken).Resume(st)
+	if err := w.Run(); err != nil {
+		t.Fatalf("expected resumed run to succeed, got %v", err)
+	}
+	if calls["fetch"] != 1 || calls["build"] != 2 || calls["test"] != 1 {
+		t.Errorf("expected only build and test to rerun, got %v", calls)
+	}
+	if got := w.Steps[0].Actual.Output; got != "fetch ok" {
+		t.Errorf("expected fetch output to be restored, got %q", got)
+	}
+	res := w.Result()
+	if res.RunID != st.RunID || res.Task("fetch").Status != TaskStatusSkipped || res.Count(TaskStatusSucceeded) != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	st, err = LoadRunState(dir, st.RunID)
+	if err != nil {
+		t.Fatalf("LoadRunState failed: %v", err)
+	}
+	for _, name := range []string{"fetch", "build", "test"} {
+		if st.Tasks[name].Status != TaskStatusSucceeded {
+			t.Errorf("expected %s to be saved as succeeded, got %s", name, st.Tasks[name].Status)
+		}
+	}
+}
+
+func TestWorkflow_ResumeRejectsChangedTask(t *testing.T) {
+	dir := t.TempDir()
+	calls := map[string]int{}
+	broken := true
+	w := resumeWorkflow(dir, calls, &broken)
+	_ = w.Run()
+	st, err := LoadRunState(dir, w.RunID)
+	if err != nil {
+		t.Fatalf("LoadRunState failed: %v", err)
+	}
+
+	// Changing a failed task is fine; changing a succeeded one is not.
+	w = resumeWorkflow(dir, calls, &broken).Resume(st)
+	w.Steps[1].Args = []string{"-j4"}
+	broken = false
+	if err := w.Run(); err != nil {
+		t.Fatalf("expected resume with changed failed task to succeed, got %v", err)
+	}
+
+	w = resumeWorkflow(dir, calls, &broken).Resume(st)
+	w.Steps[0].Args = []string{"--depth=1"}
+	err = w.Run()
+	if err == nil || !strings.Contains(err.Error(), "task fetch changed since run") {
+		t.Errorf("expected changed task error, got %v", err)
+	}
+
+	other := NewWorkflow("other", zap.NewNop()).Resume(st)
+	other.AddTask(Task{Name: "fetch", Command: "true"})
+	if err := other.Run(); err == nil || !strings.Contains(err.Error(), "belongs to workflow") {
+		t.Errorf("expected workflow mismatch error, got %v", err)
+	}
+}
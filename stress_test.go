@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -88,3 +89,31 @@ func TestWorkflow_AdvancedStress(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// Large DAG: 10k tasks in a wide fan-out/fan-in shape must complete without polling delays
+func TestWorkflow_LargeDAGStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large DAG stress test in short mode")
+	}
+	const width = 5000
+	steps := make([]Task, 0, 2*width)
+	for i := 0; i < width; i++ {
+		steps = append(steps, Task{Name: fmt.Sprintf("a%d", i), Command: "true"})
+	}
+	for i := 0; i < width; i++ {
+		steps = append(steps, Task{
+			Name:    fmt.Sprintf("b%d", i),
+			Command: "true",
+			Depends: []string{fmt.Sprintf("a%d", i), fmt.Sprintf("a%d", (i+1)%width)},
+		})
+	}
+	var completed atomic.Int64
+	wf := Workflow{Steps: steps, logger: zap.NewNop()}
+	wf.AddOnTaskCompleteHook(func(*Task) { completed.Add(1) })
+	if err := wf.Run(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	if got := completed.Load(); got != 2*width {
+		t.Errorf("expected %d completed tasks, got %d", 2*width, got)
+	}
+}
@@ -0,0 +1,155 @@
+package iapetus
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Structured output formats understood by ParseStructuredOutput.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatCSV  = "csv"
+)
+
+// ParseStructuredOutput parses s in the given format into JSON-compatible values
+// (maps, slices, strings, float64, bool, nil), so every format can share the
+// JSON diff, skip paths and path queries.
+//
+//   - yaml: a single document yields its value; a multi-document stream ("---")
+//     yields a list of documents.
+//   - toml: yields a table (object).
+//   - csv: the first row is the header; yields a list of objects keyed by header.
+func ParseStructuredOutput(format, s string) (interface{}, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return v, nil
+	case FormatYAML, "yml":
+		return parseYAMLDocuments(s)
+	case FormatTOML:
+		var v map[string]interface{}
+		if _, err := toml.Decode(s, &v); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+		return toJSONCompatible(v)
+	case FormatCSV:
+		return parseCSVRecords(s)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// isStructuredFormat reports whether ParseStructuredOutput understands format.
+func isStructuredFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case FormatJSON, FormatYAML, "yml", FormatTOML, FormatCSV:
+		return true
+	}
+	return false
+}
+
+// parseYAMLDocuments decodes one or more YAML documents.
+func parseYAMLDocuments(s string) (interface{}, error) {
+	dec := yaml.NewDecoder(strings.NewReader(s))
+	var docs []interface{}
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		if doc == nil {
+			// Skip empty documents (e.g. a leading or trailing "---")
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	var v interface{}
+	switch len(docs) {
+	case 0:
+		v = nil
+	case 1:
+		v = docs[0]
+	default:
+		v = docs
+	}
+	return toJSONCompatible(v)
+}
+
+// parseCSVRecords decodes CSV with a header row into a list of objects.
+func parseCSVRecords(s string) (interface{}, error) {
+	r := csv.NewReader(bytes.NewBufferString(s))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	rows := make([]interface{}, 0, len(records))
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, rec := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = rec[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// toJSONCompatible converts decoded YAML/TOML values to what encoding/json would produce.
+func toJSONCompatible(v interface{}) (interface{}, error) {
+	return normalizeJSONValue(stringifyKeys(v))
+}
+
+// stringifyKeys converts map[interface{}]interface{} (non-string YAML keys) to map[string]interface{}.
+func stringifyKeys(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, child := range node {
+			out[fmt.Sprint(k)] = stringifyKeys(child)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, child := range node {
+			out[k] = stringifyKeys(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, child := range node {
+			out[i] = stringifyKeys(child)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(node))
+		for i, child := range node {
+			out[i] = stringifyKeys(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,118 @@
+package iapetus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStructuredOutput(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+		want   interface{}
+	}{
+		{FormatJSON, `{"a": 1}`, map[string]interface{}{"a": 1.0}},
+		{FormatYAML, "a: 1\nb: [x, y]\n", map[string]interface{}{"a": 1.0, "b": []interface{}{"x", "y"}}},
+		{FormatYAML, "---\nkind: Service\n---\nkind: Deployment\n---\n", []interface{}{
+			map[string]interface{}{"kind": "Service"},
+			map[string]interface{}{"kind": "Deployment"},
+		}},
+		{FormatYAML, "1: one\n", map[string]interface{}{"1": "one"}},
+		{FormatTOML, "title = \"x\"\n[server]\nport = 8080\n", map[string]interface{}{
+			"title":  "x",
+			"server": map[string]interface{}{"port": 8080.0},
+		}},
+		{FormatCSV, "name,age\nalice,30\nbob,41\n", []interface{}{
+			map[string]interface{}{"name": "alice", "age": "30"},
+			map[string]interface{}{"name": "bob", "age": "41"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseStructuredOutput(tt.format, tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.format, got, tt.want)
+		}
+	}
+
+	if _, err := ParseStructuredOutput("xml", "<a/>"); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+	if _, err := ParseStructuredOutput(FormatYAML, "a: [1"); err == nil {
+		t.Errorf("expected error for invalid YAML")
+	}
+}
+
+func TestAssertOutputYamlEquals(t *testing.T) {
+	task := &Task{Actual: Output{Output: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  uid: 1234
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  uid: 5678
+`}}
+	expected := `
+kind: Service
+apiVersion: v1
+metadata: {name: web}
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata: {name: web}
+`
+	if err := AssertOutputYamlEquals(expected, "*.metadata.uid")(task); err != nil {
+		t.Errorf("expected YAML to match with skipped uid, got %v", err)
+	}
+	err := AssertOutputYamlEquals(expected)(task)
+	if err == nil || !strings.Contains(err.Error(), "mismatch at path 0.metadata.uid") {
+		t.Errorf("expected uid mismatch, got %v", err)
+	}
+}
+
+func TestAssertOutputStructuredEquals(t *testing.T) {
+	toml := &Task{Actual: Output{Output: "title = \"x\"\n[server]\nport = 8080\n"}}
+	if err := AssertOutputStructuredEquals(FormatTOML, "title = \"x\"\n\n[server]\nport = 8080", JSONCompareOptions{})(toml); err != nil {
+		t.Errorf("expected TOML to match, got %v", err)
+	}
+	err := AssertOutputStructuredEquals(FormatTOML, "title = \"x\"\n[server]\nport = 9090", JSONCompareOptions{})(toml)
+	if err == nil || !strings.Contains(err.Error(), "mismatch at path server.port: expected 9090, got 8080") {
+		t.Errorf("expected port mismatch, got %v", err)
+	}
+
+	csv := &Task{Actual: Output{Output: "name,age\nbob,41\nalice,30\n"}}
+	if err := AssertOutputStructuredEquals(FormatCSV, "name,age\nalice,30\nbob,41", JSONCompareOptions{IgnoreArrayOrder: true})(csv); err != nil {
+		t.Errorf("expected CSV to match ignoring order, got %v", err)
+	}
+	if err := AssertOutputStructuredEquals(FormatCSV, "name,age\nalice,31\nbob,41", JSONCompareOptions{})(csv); err == nil {
+		t.Errorf("expected CSV mismatch")
+	}
+	if err := AssertOutputStructuredEquals(FormatTOML, "a = 1", JSONCompareOptions{})(&Task{Actual: Output{Output: "not toml ["}}); err == nil {
+		t.Errorf("expected parse error for invalid output")
+	}
+}
+
+func TestAssertOutputPath_YAML(t *testing.T) {
+	task := &Task{Actual: Output{Output: "kind: Service\n---\nkind: Deployment\nspec:\n  replicas: 3\n"}}
+	if err := AssertYAMLPathEquals("[1].spec.replicas", 3)(task); err != nil {
+		t.Errorf("expected replicas to match, got %v", err)
+	}
+	if err := AssertOutputPath(FormatYAML, "[*].kind", PathContains("Deployment"))(task); err != nil {
+		t.Errorf("expected kinds to contain Deployment, got %v", err)
+	}
+	if err := AssertOutputPath(FormatYAML, "[*]", PathLength(2))(task); err != nil {
+		t.Errorf("expected two documents, got %v", err)
+	}
+	if err := AssertYAMLPathEquals("[0].kind", "Deployment")(task); err == nil {
+		t.Errorf("expected mismatch for first document kind")
+	}
+}
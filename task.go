@@ -1,7 +1,9 @@
 package iapetus
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -44,6 +46,15 @@ type Task struct {
 	WorkingDir string // Working dir
 	// Depends lists the names of tasks this task depends on.
 	Depends []string // Dependencies for the task
+	// Cache opts the task into result caching (see CacheOptions and Workflow.CacheDir).
+	Cache *CacheOptions `json:"cache,omitempty" yaml:"cache,omitempty"`
+	// Tags label the task for selecting subsets of a workflow (see Selection).
+	Tags []string `json:"tags" yaml:"tags"` // Labels for --tags/--skip-tags
+	// Priority orders ready tasks when the workflow's MaxParallel limit is reached; higher starts first.
+	Priority int `json:"priority" yaml:"priority"` // Scheduling priority (default 0)
+	// AlwaysRun makes the task run even if the workflow stops early (a task failed,
+	// the run timed out or was cancelled), e.g. for teardown. See Workflow.Run.
+	AlwaysRun bool `json:"always_run" yaml:"always_run"` // Teardown task
 	// Actual holds the actual output and results of the command execution.
 	Actual Output // Actual command output and results
 	// Asserts is a list of custom validation functions (assertions).
@@ -51,6 +62,26 @@ type Task struct {
 	// logger is the zap logger used for this task.
 	logger  *zap.Logger // Logger for this task
 	Backend string      // Per-task backend override
+	// Func is an in-process handler run by the "func" backend (optional).
+	Func TaskFunc `json:"-" yaml:"-"`
+	// HTTP is the request sent by the "http" backend (optional).
+	HTTP *HTTPRequest `json:"http,omitempty" yaml:"http,omitempty"`
+	// Workflow is a nested workflow run by the "workflow" backend (optional).
+	Workflow *SubWorkflow `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+	// Generate makes the task spawn tasks from its JSON list output (optional).
+	Generate *Generator `json:"generate,omitempty" yaml:"-"`
+	// OutputWriter, if set, receives the command's output as it is produced by
+	// backends that stream it (bash, docker, kubernetes and plugins). Actual.Output
+	// still holds the complete output.
+	OutputWriter io.Writer `json:"-" yaml:"-"`
+	// backends is the owning workflow's registry, consulted before the global one.
+	backends *BackendRegistry
+	// parent is the workflow running the task, set by Workflow.Run.
+	parent *Workflow
+	// generatedBy names the generator that spawned the task, if any.
+	generatedBy string
+	// ctx is the context of the current run, set by RunContext.
+	ctx context.Context
 }
 
 // Output holds the execution results of a command, including its exit code,
@@ -66,6 +97,18 @@ type Output struct {
 	Contains []string // Strings that should be present in the output
 	// Patterns are regular expression patterns to match against the output.
 	Patterns []string // Regular expression pattern to match against the output
+	// StatusCode is the HTTP response status code (http backend only).
+	StatusCode int // HTTP response status code
+	// Headers are the HTTP response headers (http backend only).
+	Headers map[string][]string // HTTP response headers
+	// Duration is how long the last attempt took to execute.
+	Duration time.Duration // Execution time of the last attempt
+	// Workflow is the result of the nested run (workflow backend only).
+	Workflow *RunResult // Nested workflow result
+	// Env holds variables the task exported by writing KEY=VALUE lines to $IAPETUS_ENV.
+	Env map[string]string // Exported environment side effects
+	// Warnings are non-fatal assertion failures (see Warn) from the last attempt.
+	Warnings []*AssertionError // Non-fatal assertion findings
 }
 
 // NewTask creates a new Task instance with the specified name and timeout.
@@ -91,12 +134,39 @@ func (t *Task) SetBackend(backend string) *Task {
 	return t
 }
 
+// SetFunc sets an in-process handler for this task and selects the "func" backend.
+func (t *Task) SetFunc(fn TaskFunc) *Task {
+	t.Func = fn
+	t.Backend = "func"
+	return t
+}
+
+// SetHTTP sets the request for this task and selects the "http" backend.
+func (t *Task) SetHTTP(req *HTTPRequest) *Task {
+	t.HTTP = req
+	t.Backend = "http"
+	return t
+}
+
+// SetWorkflow sets the nested workflow for this task and selects the "workflow" backend.
+func (t *Task) SetWorkflow(sw *SubWorkflow) *Task {
+	t.Workflow = sw
+	t.Backend = "workflow"
+	return t
+}
+
 // getBackend returns the backend for this task, falling back to workflow or default.
+// The workflow registry (if any) takes precedence over the global registry.
 func (t *Task) getBackend() Backend {
 	backendName := t.Backend
 	if backendName == "" {
 		backendName = DefaultBackend
 	}
+	if t.backends != nil {
+		if b := t.backends.Get(backendName); b != nil {
+			return b
+		}
+	}
 	return GetBackend(backendName)
 }
 
@@ -116,6 +186,16 @@ func (t *Task) EnsureDefaults() {
 // Run executes the task with configured retries and assertions.
 // It uses the plugin backend if available, or returns an error if not found.
 func (t *Task) Run() error {
+	return t.RunContext(context.Background())
+}
+
+// RunContext is like Run, but stops when ctx is done: backends derive the context
+// of every attempt from it (see Context), and no retries start after it is done.
+// Workflow.RunContext runs tasks with the workflow's context, so a workflow Timeout
+// or cancellation interrupts them.
+func (t *Task) RunContext(ctx context.Context) error {
+	t.ctx = ctx
+	defer func() { t.ctx = nil }()
 	t.EnsureDefaults()
 	if t.Name == "" {
 		t.Name = "task-" + uuid.New().String()
@@ -126,26 +206,26 @@ func (t *Task) Run() error {
 	if t.Retries == 0 {
 		t.Retries = 1
 	}
-	if t.Command == "" {
+	if t.Command == "" && t.Func == nil && t.HTTP == nil && t.Workflow == nil {
 		t.logger.Error("Task command is required", zap.String("task", t.Name))
-		return fmt.Errorf("task %s: command is required", t.Name)
+		return &ValidationError{Task: t.Name, Err: fmt.Errorf("task %s: command is required", t.Name)}
 	}
 	t.logger.Info("Running task", zap.String("task", t.Name), zap.String("backend", t.Backend))
 
 	backend := t.getBackend()
 	if backend == nil {
 		t.logger.Error("No backend found", zap.String("backend", t.Backend))
-		return fmt.Errorf("backend %s not found", t.Backend)
+		return &BackendNotFoundError{Task: t.Name, Backend: t.Backend}
 	}
 
 	// If the backend implements Validator, validate the task before running
 
 	if err := backend.ValidateTask(t); err != nil {
 		t.logger.Error("Task validation failed", zap.String("task", t.Name), zap.Error(err))
-		return err
+		return &ValidationError{Task: t.Name, Backend: backend.GetName(), Err: err}
 	}
 
-	var lastErr error
+	var attempts []error
 	// Use per-task retry delay if set, otherwise default to 1s
 	retryDelay := t.RetryDelay
 	if retryDelay == 0 {
@@ -155,17 +235,23 @@ func (t *Task) Run() error {
 		t.logger.Debug("Attempt", zap.Int("attempt", attempt), zap.Int("retries", t.Retries), zap.String("task", t.Name))
 		err := backend.RunTask(t)
 		if err != nil {
-			lastErr = err
+			attempts = append(attempts, err)
 			if attempt < t.Retries {
-				t.logger.Debug("Retrying task after failure", zap.String("task", t.Name), zap.Duration("retry_delay", retryDelay))
-				time.Sleep(retryDelay)
-				continue
+				if ctx.Err() == nil {
+					t.logger.Debug("Retrying task after failure", zap.String("task", t.Name), zap.Duration("retry_delay", retryDelay))
+					select {
+					case <-time.After(retryDelay):
+						continue
+					case <-ctx.Done():
+					}
+				}
+				return &InterruptedError{Task: t.Name, Cause: context.Cause(ctx), Retries: t.Retries, Attempts: attempts}
 			}
-			return fmt.Errorf("task %s failed after %d attempts: %w", t.Name, t.Retries, err)
+			return &RetriesExhaustedError{Task: t.Name, Attempts: attempts}
 		}
 		return nil
 	}
-	return lastErr
+	return nil
 }
 
 // AddAssertion registers a new assertion function to validate the task execution.
@@ -175,6 +261,12 @@ func (t *Task) AddAssertion(assert func(*Task) error) *Task {
 	return t
 }
 
+// AddWarning registers an assertion whose failures are recorded as warnings
+// (Actual.Warnings and the run result) instead of failing the task.
+func (t *Task) AddWarning(assert func(*Task) error) *Task {
+	return t.AddAssertion(Warn(assert))
+}
+
 // AddArgs appends command line arguments to the task.
 func (t *Task) AddArgs(args ...string) *Task {
 	t.Args = append(t.Args, args...)
@@ -193,6 +285,25 @@ func (t *Task) SetRetries(retry int) *Task {
 	return t
 }
 
+// EnableCache opts the task into result caching, keyed additionally on the
+// content of the files matched by the input globs.
+func (t *Task) EnableCache(inputs ...string) *Task {
+	t.Cache = &CacheOptions{Inputs: inputs}
+	return t
+}
+
+// AddTags adds labels used to select subsets of a workflow.
+func (t *Task) AddTags(tags ...string) *Task {
+	t.Tags = append(t.Tags, tags...)
+	return t
+}
+
+// SetPriority sets the scheduling priority of the task. Higher values start first.
+func (t *Task) SetPriority(priority int) *Task {
+	t.Priority = priority
+	return t
+}
+
 // AssertExitCode adds an assertion that checks the exit code of the task.
 func (t *Task) AssertExitCode(code int) *Task {
 	return t.AddAssertion(AssertExitCode(code))
@@ -203,6 +314,21 @@ func (t *Task) AssertOutputContains(substr string) *Task {
 	return t.AddAssertion(AssertOutputContains(substr))
 }
 
+// AssertOutputNotContains adds an assertion that checks the output does not contain a substring.
+func (t *Task) AssertOutputNotContains(substr string) *Task {
+	return t.AddAssertion(AssertOutputNotContains(substr))
+}
+
+// AssertExitCodeIn adds an assertion that checks the exit code is one of codes.
+func (t *Task) AssertExitCodeIn(codes ...int) *Task {
+	return t.AddAssertion(AssertExitCodeIn(codes...))
+}
+
+// AssertExitCodeInRange adds an assertion that checks min <= exit code <= max.
+func (t *Task) AssertExitCodeInRange(min, max int) *Task {
+	return t.AddAssertion(AssertExitCodeInRange(min, max))
+}
+
 // AssertOutputEquals adds an assertion that checks if output matches exactly.
 func (t *Task) AssertOutputEquals(expected string) *Task {
 	return t.AddAssertion(AssertOutputEquals(expected))
@@ -213,11 +339,121 @@ func (t *Task) AssertOutputJsonEquals(expected string, skipJsonNodes ...string)
 	return t.AddAssertion(AssertOutputJsonEquals(expected, skipJsonNodes...))
 }
 
+// AssertOutputJsonEqualsWithOptions adds a JSON equality assertion with comparison options.
+func (t *Task) AssertOutputJsonEqualsWithOptions(expected string, opts JSONCompareOptions) *Task {
+	return t.AddAssertion(AssertOutputJsonEqualsWithOptions(expected, opts))
+}
+
+// AssertOutputYamlEquals adds an assertion that checks if YAML output matches expected YAML.
+func (t *Task) AssertOutputYamlEquals(expected string, skipNodes ...string) *Task {
+	return t.AddAssertion(AssertOutputYamlEquals(expected, skipNodes...))
+}
+
+// AssertOutputStructuredEquals adds an equality assertion for json, yaml, toml or csv output.
+func (t *Task) AssertOutputStructuredEquals(format, expected string, opts JSONCompareOptions) *Task {
+	return t.AddAssertion(AssertOutputStructuredEquals(format, expected, opts))
+}
+
+// AssertOutputMatchesGolden adds an assertion that compares normalized output to a golden file.
+func (t *Task) AssertOutputMatchesGolden(path string, normalizers ...Normalizer) *Task {
+	return t.AddAssertion(AssertOutputMatchesGolden(path, normalizers...))
+}
+
 // AssertOutputMatchesRegexp adds an assertion that checks if output matches a regexp.
 func (t *Task) AssertOutputMatchesRegexp(pattern string) *Task {
 	return t.AddAssertion(AssertOutputMatchesRegexp(pattern))
 }
 
+// AssertStatusCode adds an assertion that checks the HTTP response status code.
+func (t *Task) AssertStatusCode(code int) *Task {
+	return t.AddAssertion(AssertStatusCode(code))
+}
+
+// AssertHeaderEquals adds an assertion that checks an HTTP response header value.
+func (t *Task) AssertHeaderEquals(name, value string) *Task {
+	return t.AddAssertion(AssertHeaderEquals(name, value))
+}
+
+// AssertJSONPathEquals adds an assertion that checks the value at a JSON path in the output.
+func (t *Task) AssertJSONPathEquals(path string, expected interface{}) *Task {
+	return t.AddAssertion(AssertJSONPathEquals(path, expected))
+}
+
+// AssertJSONPathContains adds an assertion that checks the value at a JSON path contains expected.
+func (t *Task) AssertJSONPathContains(path string, expected interface{}) *Task {
+	return t.AddAssertion(AssertJSONPathContains(path, expected))
+}
+
+// AssertJSONPathGreaterThan adds an assertion that checks the number at a JSON path is greater than min.
+func (t *Task) AssertJSONPathGreaterThan(path string, min float64) *Task {
+	return t.AddAssertion(AssertJSONPathGreaterThan(path, min))
+}
+
+// AssertJSONPathLength adds an assertion that checks the length of the value at a JSON path.
+func (t *Task) AssertJSONPathLength(path string, length int) *Task {
+	return t.AddAssertion(AssertJSONPathLength(path, length))
+}
+
+// AssertJSONPathMatches adds an assertion that checks the value at a JSON path matches a regexp.
+func (t *Task) AssertJSONPathMatches(path string, pattern string) *Task {
+	return t.AddAssertion(AssertJSONPathMatches(path, pattern))
+}
+
+// AssertYAMLPathEquals adds an assertion that checks the value at a path in YAML output.
+func (t *Task) AssertYAMLPathEquals(path string, expected interface{}) *Task {
+	return t.AddAssertion(AssertYAMLPathEquals(path, expected))
+}
+
+// AssertDurationUnder adds an assertion that checks the task completed in less than max.
+func (t *Task) AssertDurationUnder(max time.Duration) *Task {
+	return t.AddAssertion(AssertDurationUnder(max))
+}
+
+// AssertFileExists adds an assertion that checks a file exists (relative to WorkingDir).
+func (t *Task) AssertFileExists(path string) *Task {
+	return t.AddAssertion(AssertFileExists(path))
+}
+
+// AssertFileContains adds an assertion that checks a file contains a substring.
+func (t *Task) AssertFileContains(path, substr string) *Task {
+	return t.AddAssertion(AssertFileContains(path, substr))
+}
+
+// AssertFileChecksum adds an assertion that checks the SHA-256 digest of a file.
+func (t *Task) AssertFileChecksum(path, sha256 string) *Task {
+	return t.AddAssertion(AssertFileChecksum(path, sha256))
+}
+
+// AssertFileJSONEquals adds an assertion that checks a JSON file matches expected JSON.
+func (t *Task) AssertFileJSONEquals(path, expected string, opts JSONCompareOptions) *Task {
+	return t.AddAssertion(AssertFileJSONEquals(path, expected, opts))
+}
+
+// AssertEnvEquals adds an assertion that checks a variable exported via $IAPETUS_ENV.
+func (t *Task) AssertEnvEquals(name, value string) *Task {
+	return t.AddAssertion(AssertEnvEquals(name, value))
+}
+
+// AssertEnvSet adds an assertion that checks a variable was exported via $IAPETUS_ENV.
+func (t *Task) AssertEnvSet(name string) *Task {
+	return t.AddAssertion(AssertEnvSet(name))
+}
+
+// AssertExpr adds a CEL expression assertion (see AssertExpr for the available variables).
+// If the expression does not compile, the assertion fails with the compile error.
+func (t *Task) AssertExpr(expression string) *Task {
+	assert, err := AssertExpr(expression)
+	if err != nil {
+		return t.AddAssertion(func(*Task) error { return err })
+	}
+	return t.AddAssertion(assert)
+}
+
+// AssertOutputJSONSchema adds an assertion that validates the output against a JSON Schema.
+func (t *Task) AssertOutputJSONSchema(schema string) *Task {
+	return t.AddAssertion(AssertOutputJSONSchema(schema))
+}
+
 // Expect returns a new TaskAssertionBuilder for chaining assertions in a fluent style.
 func (t *Task) Expect() *TaskAssertionBuilder {
 	return &TaskAssertionBuilder{task: t}
@@ -247,18 +483,162 @@ func (b *TaskAssertionBuilder) OutputEquals(expected string) *TaskAssertionBuild
 	return b
 }
 
+// OutputNotContains adds a negative substring assertion to the builder.
+func (b *TaskAssertionBuilder) OutputNotContains(substr string) *TaskAssertionBuilder {
+	b.task.AssertOutputNotContains(substr)
+	return b
+}
+
+// ExitCodeIn adds an exit code set assertion to the builder.
+func (b *TaskAssertionBuilder) ExitCodeIn(codes ...int) *TaskAssertionBuilder {
+	b.task.AssertExitCodeIn(codes...)
+	return b
+}
+
+// ExitCodeInRange adds an exit code range assertion to the builder.
+func (b *TaskAssertionBuilder) ExitCodeInRange(min, max int) *TaskAssertionBuilder {
+	b.task.AssertExitCodeInRange(min, max)
+	return b
+}
+
 // OutputJsonEquals adds a JSON output equality assertion to the builder.
 func (b *TaskAssertionBuilder) OutputJsonEquals(expected string, skipJsonNodes ...string) *TaskAssertionBuilder {
 	b.task.AssertOutputJsonEquals(expected, skipJsonNodes...)
 	return b
 }
 
+// OutputJsonEqualsWithOptions adds a JSON output equality assertion with comparison options to the builder.
+func (b *TaskAssertionBuilder) OutputJsonEqualsWithOptions(expected string, opts JSONCompareOptions) *TaskAssertionBuilder {
+	b.task.AssertOutputJsonEqualsWithOptions(expected, opts)
+	return b
+}
+
+// OutputYamlEquals adds a YAML output equality assertion to the builder.
+func (b *TaskAssertionBuilder) OutputYamlEquals(expected string, skipNodes ...string) *TaskAssertionBuilder {
+	b.task.AssertOutputYamlEquals(expected, skipNodes...)
+	return b
+}
+
+// OutputStructuredEquals adds a json, yaml, toml or csv output equality assertion to the builder.
+func (b *TaskAssertionBuilder) OutputStructuredEquals(format, expected string, opts JSONCompareOptions) *TaskAssertionBuilder {
+	b.task.AssertOutputStructuredEquals(format, expected, opts)
+	return b
+}
+
+// OutputMatchesGolden adds a golden file assertion to the builder.
+func (b *TaskAssertionBuilder) OutputMatchesGolden(path string, normalizers ...Normalizer) *TaskAssertionBuilder {
+	b.task.AssertOutputMatchesGolden(path, normalizers...)
+	return b
+}
+
 // OutputMatchesRegexp adds a regexp output assertion to the builder.
 func (b *TaskAssertionBuilder) OutputMatchesRegexp(pattern string) *TaskAssertionBuilder {
 	b.task.AssertOutputMatchesRegexp(pattern)
 	return b
 }
 
+// StatusCode adds an HTTP status code assertion to the builder.
+func (b *TaskAssertionBuilder) StatusCode(code int) *TaskAssertionBuilder {
+	b.task.AssertStatusCode(code)
+	return b
+}
+
+// HeaderEquals adds an HTTP response header assertion to the builder.
+func (b *TaskAssertionBuilder) HeaderEquals(name, value string) *TaskAssertionBuilder {
+	b.task.AssertHeaderEquals(name, value)
+	return b
+}
+
+// JSONPathEquals adds a JSON path equality assertion to the builder.
+func (b *TaskAssertionBuilder) JSONPathEquals(path string, expected interface{}) *TaskAssertionBuilder {
+	b.task.AssertJSONPathEquals(path, expected)
+	return b
+}
+
+// JSONPathContains adds a JSON path contains assertion to the builder.
+func (b *TaskAssertionBuilder) JSONPathContains(path string, expected interface{}) *TaskAssertionBuilder {
+	b.task.AssertJSONPathContains(path, expected)
+	return b
+}
+
+// JSONPathGreaterThan adds a JSON path numeric comparison assertion to the builder.
+func (b *TaskAssertionBuilder) JSONPathGreaterThan(path string, min float64) *TaskAssertionBuilder {
+	b.task.AssertJSONPathGreaterThan(path, min)
+	return b
+}
+
+// JSONPathLength adds a JSON path length assertion to the builder.
+func (b *TaskAssertionBuilder) JSONPathLength(path string, length int) *TaskAssertionBuilder {
+	b.task.AssertJSONPathLength(path, length)
+	return b
+}
+
+// JSONPathMatches adds a JSON path regexp assertion to the builder.
+func (b *TaskAssertionBuilder) JSONPathMatches(path string, pattern string) *TaskAssertionBuilder {
+	b.task.AssertJSONPathMatches(path, pattern)
+	return b
+}
+
+// YAMLPathEquals adds a YAML path equality assertion to the builder.
+func (b *TaskAssertionBuilder) YAMLPathEquals(path string, expected interface{}) *TaskAssertionBuilder {
+	b.task.AssertYAMLPathEquals(path, expected)
+	return b
+}
+
+// DurationUnder adds a duration assertion to the builder.
+func (b *TaskAssertionBuilder) DurationUnder(max time.Duration) *TaskAssertionBuilder {
+	b.task.AssertDurationUnder(max)
+	return b
+}
+
+// FileExists adds a file existence assertion to the builder.
+func (b *TaskAssertionBuilder) FileExists(path string) *TaskAssertionBuilder {
+	b.task.AssertFileExists(path)
+	return b
+}
+
+// FileContains adds a file contents assertion to the builder.
+func (b *TaskAssertionBuilder) FileContains(path, substr string) *TaskAssertionBuilder {
+	b.task.AssertFileContains(path, substr)
+	return b
+}
+
+// FileChecksum adds a file checksum assertion to the builder.
+func (b *TaskAssertionBuilder) FileChecksum(path, sha256 string) *TaskAssertionBuilder {
+	b.task.AssertFileChecksum(path, sha256)
+	return b
+}
+
+// FileJSONEquals adds a JSON file equality assertion to the builder.
+func (b *TaskAssertionBuilder) FileJSONEquals(path, expected string, opts JSONCompareOptions) *TaskAssertionBuilder {
+	b.task.AssertFileJSONEquals(path, expected, opts)
+	return b
+}
+
+// EnvEquals adds an exported variable assertion to the builder.
+func (b *TaskAssertionBuilder) EnvEquals(name, value string) *TaskAssertionBuilder {
+	b.task.AssertEnvEquals(name, value)
+	return b
+}
+
+// EnvSet adds an exported variable presence assertion to the builder.
+func (b *TaskAssertionBuilder) EnvSet(name string) *TaskAssertionBuilder {
+	b.task.AssertEnvSet(name)
+	return b
+}
+
+// Expr adds a CEL expression assertion to the builder.
+func (b *TaskAssertionBuilder) Expr(expression string) *TaskAssertionBuilder {
+	b.task.AssertExpr(expression)
+	return b
+}
+
+// OutputJSONSchema adds a JSON Schema validation assertion to the builder.
+func (b *TaskAssertionBuilder) OutputJSONSchema(schema string) *TaskAssertionBuilder {
+	b.task.AssertOutputJSONSchema(schema)
+	return b
+}
+
 // Done returns the parent Task for further chaining.
 func (b *TaskAssertionBuilder) Done() *Task {
 	return b.task
@@ -276,6 +656,40 @@ func (t *Task) AddEnvMap(envMap map[string]string) *Task {
 	return t
 }
 
+// Context returns the context of the task's current run, or context.Background()
+// outside of RunContext. Backends should derive the context bounding an attempt
+// from it, so the task stops when its workflow times out or is cancelled.
+func (t *Task) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// gracePeriod returns how long the task's command may take to exit after its
+// workflow is cancelled or times out: the workflow's GracePeriod or DefaultGracePeriod.
+func (t *Task) gracePeriod() time.Duration {
+	if t.parent != nil && t.parent.GracePeriod > 0 {
+		return t.parent.GracePeriod
+	}
+	return DefaultGracePeriod
+}
+
+// SetAlwaysRun makes the task run even if the workflow stops early (see AlwaysRun).
+func (t *Task) SetAlwaysRun() *Task {
+	t.AlwaysRun = true
+	return t
+}
+
+// contextError returns the error of an attempt whose context (derived from
+// Context) is done: the run's cancellation cause, or else the task's own timeout.
+func (t *Task) contextError() error {
+	if cause := context.Cause(t.Context()); cause != nil {
+		return fmt.Errorf("task %s interrupted: %w", t.Name, cause)
+	}
+	return &TimeoutError{Task: t.Name, Timeout: t.Timeout}
+}
+
 // Logger returns the zap.Logger for this task, ensuring it is set.
 func (t *Task) Logger() *zap.Logger {
 	t.EnsureDefaults()
@@ -17,7 +17,7 @@ func TestIntegrationTest_Run(t *testing.T) {
 		},
 	}
 	test.SetBackend("bash")
-	iapetus.RegisterBackend("bash", &iapetus.BashBackend{})
+	registerTestBackend(t, "bash", &iapetus.BashBackend{})
 
 	err := test.Run()
 	if err != nil {
@@ -67,7 +67,7 @@ func TestIntegrationTest_RunCommandError(t *testing.T) {
 		},
 	}
 	test.SetBackend("bash")
-	iapetus.RegisterBackend("bash", &iapetus.BashBackend{})
+	registerTestBackend(t, "bash", &iapetus.BashBackend{})
 
 	err := test.Run()
 	if err == nil {
@@ -143,10 +143,19 @@ func TestTask_ExpectDSL(t *testing.T) {
 	}
 }
 
+// registerTestBackend swaps a global backend for the duration of the test.
 func registerTestBackend(t *testing.T, name string, backend iapetus.Backend) {
-	iapetus.RegisterBackend(name, backend)
+	t.Helper()
+	previous := iapetus.GetBackend(name)
+	iapetus.UnregisterBackend(name)
+	if err := iapetus.RegisterBackend(name, backend); err != nil {
+		t.Fatalf("failed to register backend %s: %v", name, err)
+	}
 	t.Cleanup(func() {
-		// No-op: add backend registry reset here if needed for isolation
+		iapetus.UnregisterBackend(name)
+		if previous != nil {
+			_ = iapetus.RegisterBackend(name, previous)
+		}
 	})
 }
 
@@ -208,7 +217,7 @@ func (b *testBackend) GetStatus() string {
 func TestTask_DefaultsAndValidation(t *testing.T) {
 	t.Run("defaults backend/logger/env", func(t *testing.T) {
 		called := false
-		iapetus.RegisterBackend("test", &testBackend{called: &called})
+		registerTestBackend(t, "test", &testBackend{called: &called})
 		task := &iapetus.Task{Command: "echo", Backend: "test"}
 		if err := task.Run(); err != nil {
 			t.Fatalf("expected no error, got %v", err)
@@ -233,7 +242,7 @@ func TestTask_DefaultsAndValidation(t *testing.T) {
 	})
 
 	t.Run("validate error", func(t *testing.T) {
-		iapetus.RegisterBackend("test-validate", &testBackend{validateErr: fmt.Errorf("bad task")})
+		registerTestBackend(t, "test-validate", &testBackend{validateErr: fmt.Errorf("bad task")})
 		task := &iapetus.Task{Command: "echo", Backend: "test-validate"}
 		err := task.Run()
 		if err == nil || err.Error() != "bad task" {
@@ -242,7 +251,7 @@ func TestTask_DefaultsAndValidation(t *testing.T) {
 	})
 
 	t.Run("backend run error and retry", func(t *testing.T) {
-		iapetus.RegisterBackend("test-fail", &testBackend{fail: true})
+		registerTestBackend(t, "test-fail", &testBackend{fail: true})
 		task := &iapetus.Task{Command: "echo", Backend: "test-fail", Retries: 2}
 		err := task.Run()
 		if err == nil || !contains(err.Error(), "failed after 2 attempts") {
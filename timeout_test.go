@@ -0,0 +1,128 @@
+package iapetus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// blockingFunc waits until its context is done.
+func blockingFunc(ctx context.Context, t *Task) (Output, error) {
+	<-ctx.Done()
+	return Output{}, ctx.Err()
+}
+
+func TestWorkflow_Timeout(t *testing.T) {
+	w := NewWorkflow("slow", zap.NewNop()).SetTimeout(50 * time.Millisecond)
+	w.AddTask(*NewTask("fast", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) { return Output{}, nil }))
+	w.AddTask(*NewTask("hang", time.Minute, zap.NewNop()).SetFunc(blockingFunc))
+	after := NewTask("after", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) { return Output{}, nil })
+	after.Depends = []string{"hang"}
+	w.AddTask(*after)
+
+	start := time.Now()
+	err := w.Run()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the timeout to interrupt the run, took %v", elapsed)
+	}
+	var timeout *WorkflowTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected WorkflowTimeoutError, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to match context.DeadlineExceeded")
+	}
+	assert.Equal(t, "slow", timeout.WorkflowName)
+	assert.Equal(t, 50*time.Millisecond, timeout.Timeout)
+	assert.Equal(t, []string{"hang", "after"}, timeout.Unfinished)
+
+	res := w.Result()
+	assert.Equal(t, TaskStatusSucceeded, res.Task("fast").Status)
+	assert.Equal(t, TaskStatusTimedOut, res.Task("hang").Status)
+	assert.Equal(t, TaskStatusTimedOut, res.Task("after").Status)
+	assert.Equal(t, 2, res.Count(TaskStatusTimedOut))
+	if !errors.As(res.Task("hang").Err, &timeout) {
+		t.Errorf("expected interrupted task error to wrap the timeout, got %v", res.Task("hang").Err)
+	}
+}
+
+func TestWorkflow_TimeoutStopsRetries(t *testing.T) {
+	w := NewWorkflow("retry", zap.NewNop()).SetTimeout(50 * time.Millisecond)
+	attempts := 0
+	w.AddTask(*NewTask("flaky", 0, zap.NewNop()).
+		SetFunc(func(ctx context.Context, t *Task) (Output, error) {
+			attempts++
+			return Output{}, errors.New("not yet")
+		}).
+		SetRetries(10).
+		SetRetryDelay(time.Minute))
+
+	err := w.Run()
+	var timeout *WorkflowTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected WorkflowTimeoutError, got %v", err)
+	}
+	assert.Equal(t, 1, attempts)
+	res := w.Result().Task("flaky")
+	assert.Equal(t, TaskStatusTimedOut, res.Status)
+	if !strings.Contains(res.Err.Error(), "interrupted after 1 of 10 attempts") || !strings.Contains(res.Err.Error(), "not yet") {
+		t.Errorf("unexpected task error %v", res.Err)
+	}
+}
+
+func TestWorkflow_TimeoutInterruptsSubWorkflow(t *testing.T) {
+	inner := NewWorkflow("inner", zap.NewNop())
+	inner.AddTask(*NewTask("hang", time.Minute, zap.NewNop()).SetFunc(blockingFunc))
+	w := NewWorkflow("outer", zap.NewNop()).SetTimeout(50 * time.Millisecond)
+	w.AddTask(*NewTask("sub", time.Minute, zap.NewNop()).SetWorkflow(&SubWorkflow{Workflow: inner}))
+
+	err := w.Run()
+	var timeout *WorkflowTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected WorkflowTimeoutError, got %v", err)
+	}
+	assert.Equal(t, "outer", timeout.WorkflowName)
+	res := w.Result().Task("sub")
+	assert.Equal(t, TaskStatusTimedOut, res.Status)
+	assert.Equal(t, TaskStatusTimedOut, res.Workflow.Task("hang").Status)
+}
+
+func TestWorkflow_RunContextCancelled(t *testing.T) {
+	w := NewWorkflow("cancel", zap.NewNop())
+	w.AddTask(*NewTask("hang", time.Minute, zap.NewNop()).SetFunc(blockingFunc))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	err := w.RunContext(ctx)
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+	var timeout *WorkflowTimeoutError
+	if errors.As(err, &timeout) {
+		t.Errorf("expected cancellation not to be reported as a timeout")
+	}
+}
+
+func TestTask_Context(t *testing.T) {
+	task := NewTask("ctx", time.Minute, zap.NewNop()).SetFunc(blockingFunc)
+	if task.Context() != context.Background() {
+		t.Errorf("expected background context outside of a run")
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("shutting down")
+	time.AfterFunc(20*time.Millisecond, func() { cancel(cause) })
+	err := task.RunContext(ctx)
+	if !errors.Is(err, cause) {
+		t.Fatalf("expected error to wrap the cancellation cause, got %v", err)
+	}
+	if task.Context() != context.Background() {
+		t.Errorf("expected context to be cleared after the run")
+	}
+}
@@ -1,7 +1,10 @@
 package iapetus
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -9,6 +12,10 @@ import (
 
 var DefaultBackend = "bash"
 
+// DefaultGracePeriod is how long running commands get to exit after their workflow
+// is cancelled or times out, before they are killed (see Workflow.GracePeriod).
+var DefaultGracePeriod = 10 * time.Second
+
 // WorkflowError represents an error that occurred during workflow execution.
 // It contains context about which step failed and in which workflow.
 type WorkflowError struct {
@@ -25,6 +32,71 @@ func (e *WorkflowError) Error() string {
 	return fmt.Sprintf("error in step '%s' of workflow '%s': %v", e.StepName, e.WorkflowName, e.Err)
 }
 
+// Unwrap returns the underlying error so errors.Is and errors.As can inspect it.
+func (e *WorkflowError) Unwrap() error {
+	return e.Err
+}
+
+// FailedSteps returns the failing step names from this workflow down through
+// nested sub-workflows, outermost first, e.g. ["deploy", "install-chart"].
+func (e *WorkflowError) FailedSteps() []string {
+	steps := []string{e.StepName}
+	var inner *WorkflowError
+	if errors.As(e.Err, &inner) {
+		steps = append(steps, inner.FailedSteps()...)
+	}
+	return steps
+}
+
+// WorkflowTimeoutError is returned by Workflow.Run when the workflow's Timeout
+// expires. It matches context.DeadlineExceeded with errors.Is.
+type WorkflowTimeoutError struct {
+	// WorkflowName is the workflow whose Timeout expired.
+	WorkflowName string
+	// Timeout is the workflow's Timeout.
+	Timeout time.Duration
+	// Unfinished lists the tasks that were interrupted or never started, in run order.
+	Unfinished []string
+	// expired is the error the run's context was cancelled with, which tasks
+	// interrupted by the timeout wrap; nil for that error itself.
+	expired *WorkflowTimeoutError
+}
+
+// Error implements the error interface for WorkflowTimeoutError.
+func (e *WorkflowTimeoutError) Error() string {
+	return fmt.Sprintf("workflow '%s' timed out after %v with %d unfinished task(s)", e.WorkflowName, e.Timeout, len(e.Unfinished))
+}
+
+// Unwrap returns the error the run's context was cancelled with, which in turn
+// unwraps to context.DeadlineExceeded.
+func (e *WorkflowTimeoutError) Unwrap() error {
+	if e.expired != nil {
+		return e.expired
+	}
+	return context.DeadlineExceeded
+}
+
+// WorkflowCancelledError is returned by Workflow.RunContext when its context is
+// cancelled before the workflow finished. It matches the cancellation cause with errors.Is.
+type WorkflowCancelledError struct {
+	// WorkflowName is the cancelled workflow.
+	WorkflowName string
+	// Cause is the context's cancellation cause (context.Canceled unless given).
+	Cause error
+	// Unfinished lists the tasks that were interrupted or never started, in run order.
+	Unfinished []string
+}
+
+// Error implements the error interface for WorkflowCancelledError.
+func (e *WorkflowCancelledError) Error() string {
+	return fmt.Sprintf("workflow '%s' cancelled (%v) with %d unfinished task(s)", e.WorkflowName, e.Cause, len(e.Unfinished))
+}
+
+// Unwrap returns the cancellation cause.
+func (e *WorkflowCancelledError) Unwrap() error {
+	return e.Cause
+}
+
 // Workflow represents a sequence of tasks to be executed in order.
 // It provides hooks for pre and post-execution logic and maintains
 // an ordered list of tasks to be executed sequentially.
@@ -46,6 +118,63 @@ type Workflow struct {
 	OnTaskCompleteHooks []func(*Task)
 
 	Backend string `json:"backend" yaml:"backend"`
+
+	// SkipPreflight disables the backend availability and task validation pass in Run.
+	SkipPreflight bool `json:"skip_preflight" yaml:"skip_preflight"`
+
+	// Timeout bounds the wall-clock time of Run. When it expires, running tasks are
+	// interrupted through their context (see Task.Context), tasks that did not finish
+	// are marked TaskStatusTimedOut and Run returns a *WorkflowTimeoutError.
+	// Zero means no limit.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// GracePeriod is how long running commands get to exit after the run is
+	// cancelled or times out: bash commands receive SIGTERM, docker containers are
+	// stopped and kubernetes pods deleted, and whatever is left is killed once it
+	// expires. Zero uses DefaultGracePeriod.
+	GracePeriod time.Duration `json:"grace_period" yaml:"grace_period"`
+
+	// MaxParallel caps the number of tasks running at once. Zero means no limit.
+	// When more tasks are ready than may start, they start by Priority, then (with
+	// CriticalPath) by longest remaining chain, then in step order.
+	MaxParallel int `json:"max_parallel" yaml:"max_parallel"`
+	// CriticalPath ranks ready tasks of equal priority by the length of the longest
+	// dependency chain they start, so the slowest chain starts first.
+	CriticalPath bool `json:"critical_path" yaml:"critical_path"`
+	// Durations are historical task durations used to weigh chains for CriticalPath,
+	// e.g. from RunResult.Durations of a previous run. Without them every task counts as one step.
+	Durations map[string]time.Duration `json:"-" yaml:"-"`
+
+	// Selection restricts Run to a subset of the tasks; see Plan.
+	Selection Selection `json:"-" yaml:"-"`
+
+	// RunID identifies a run for resuming. If empty, a UUID is generated at runtime.
+	RunID string `json:"-" yaml:"-"`
+	// StateDir, if set, is where the run state is saved after every task (see RunState).
+	StateDir string `json:"-" yaml:"-"`
+	// CacheDir, if set, is where results of tasks with Cache set are stored and
+	// restored from. Caching is disabled if empty.
+	CacheDir string `json:"-" yaml:"-"`
+
+	// backends holds workflow-scoped backends that override the global registry.
+	backends *BackendRegistry
+
+	// resume is the state of a previous run whose succeeded tasks are skipped.
+	resume *RunState
+	// state is the state of the current run, saved to StateDir.
+	state *RunState
+
+	// result is the summary of the last Run.
+	result *RunResult
+
+	// depth is the sub-workflow nesting level; 0 for a top-level workflow.
+	depth int
+
+	// ctx is the context of the current run, set by RunContext.
+	ctx context.Context
+
+	// generated lists the names of the tasks spawned by each generator in the
+	// last run, in creation order. Written by the scheduler under its lock.
+	generated map[string][]string
 }
 
 // NewWorkflow creates a new Workflow instance with the given name.
@@ -58,6 +187,7 @@ func NewWorkflow(name string, logger *zap.Logger) *Workflow {
 		Name:                name,
 		logger:              logger,
 		Backend:             DefaultBackend,
+		backends:            NewBackendRegistry(),
 		EnvMap:              make(map[string]string), // Initialize EnvMap
 		OnTaskStartHooks:    []func(*Task){},
 		OnTaskSuccessHooks:  []func(*Task){},
@@ -66,39 +196,156 @@ func NewWorkflow(name string, logger *zap.Logger) *Workflow {
 	}
 }
 
+// SetMaxParallel limits how many tasks run at once (0 means no limit).
+func (w *Workflow) SetMaxParallel(n int) *Workflow {
+	w.MaxParallel = n
+	return w
+}
+
+// SetTimeout bounds the wall-clock time of Run (0 means no limit).
+func (w *Workflow) SetTimeout(d time.Duration) *Workflow {
+	w.Timeout = d
+	return w
+}
+
+// SetGracePeriod sets how long running commands get to exit after the run is
+// cancelled or times out (0 uses DefaultGracePeriod).
+func (w *Workflow) SetGracePeriod(d time.Duration) *Workflow {
+	w.GracePeriod = d
+	return w
+}
+
+// SetCriticalPath enables critical-path ordering of ready tasks, weighing chains
+// with the given historical durations (which may be nil).
+func (w *Workflow) SetCriticalPath(durations map[string]time.Duration) *Workflow {
+	w.CriticalPath = true
+	w.Durations = durations
+	return w
+}
+
+// Resume makes the next Run continue a previous run: tasks that succeeded in it
+// are skipped (their saved output is restored) and failed or not-yet-run tasks
+// run. The run keeps its RunID, so its state file is updated in place. Run fails
+// if a succeeded task changed since (see TaskFingerprint).
+func (w *Workflow) Resume(state *RunState) *Workflow {
+	w.resume = state
+	w.RunID = state.RunID
+	return w
+}
+
+// SetBackend sets the default backend for all tasks in the workflow.
 func (w *Workflow) SetBackend(backend string) *Workflow {
 	w.Backend = backend
 	return w
 }
 
+// RegisterBackend registers a backend for this workflow only.
+// Workflow backends take precedence over globally registered backends with the same name.
+func (w *Workflow) RegisterBackend(name string, backend Backend) error {
+	if w.backends == nil {
+		w.backends = NewBackendRegistry()
+	}
+	return w.backends.Register(name, backend)
+}
+
+// GetBackend resolves a backend by name, checking the workflow registry before the global one.
+func (w *Workflow) GetBackend(name string) Backend {
+	if w.backends != nil {
+		if b := w.backends.Get(name); b != nil {
+			return b
+		}
+	}
+	return GetBackend(name)
+}
+
 // Run executes the workflow by running all tasks in sequence.
 // It handles pre-run and post-run hooks if defined.
-// Returns an error if any step fails.
+// Before any task starts, Preflight checks backend availability and task validity
+// unless SkipPreflight is set.
+// Returns an error if any step fails. When the run stops early, AlwaysRun tasks
+// that have not started yet still run, one at a time, after running tasks finish.
+// The outcome of every task, including assertion warnings, is available from
+// Result afterwards.
 func (w *Workflow) Run() error {
+	return w.RunContext(context.Background())
+}
+
+// RunContext is like Run, but stops when ctx is done. Running tasks are
+// interrupted through their context (see GracePeriod), no further tasks start
+// except AlwaysRun ones, and RunContext returns a *WorkflowCancelledError.
+func (w *Workflow) RunContext(ctx context.Context) error {
+	start := time.Now()
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, w.Timeout, &WorkflowTimeoutError{WorkflowName: w.Name, Timeout: w.Timeout})
+		defer cancel()
+	}
+	w.ctx = ctx
+	results, err := w.run()
+	w.ctx = nil
+	w.result = w.buildResult(start, results, err)
+	return err
+}
+
+// context returns the context of the current run.
+func (w *Workflow) context() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
+// Result returns the summary of the last Run, or nil if the workflow has not run.
+func (w *Workflow) Result() *RunResult {
+	return w.result
+}
+
+// buildResult assembles the RunResult from the scheduler's per-task results in step
+// order, listing generated tasks right after their generator.
+func (w *Workflow) buildResult(start time.Time, results map[string]*TaskResult, err error) *RunResult {
+	r := &RunResult{
+		Workflow:  w.Name,
+		RunID:     w.RunID,
+		StartedAt: start,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	var add func(name string)
+	add = func(name string) {
+		if res, ok := results[name]; ok {
+			r.Tasks = append(r.Tasks, *res)
+		} else {
+			r.Tasks = append(r.Tasks, TaskResult{Name: name, Status: TaskStatusPending})
+		}
+		for _, spawned := range w.generated[name] {
+			add(spawned)
+		}
+	}
+	for i := range w.Steps {
+		add(w.Steps[i].Name)
+	}
+	return r
+}
+
+// run validates and executes the workflow, returning the per-task results.
+func (w *Workflow) run() (map[string]*TaskResult, error) {
 	w.logger.Info("Starting workflow", zap.String("workflow", w.Name))
 	if w.Name == "" {
 		w.Name = "workflow-" + uuid.New().String()
 		w.logger.Debug("Generated new workflow name", zap.String("workflow", w.Name))
 	}
+	if w.RunID == "" {
+		w.RunID = uuid.New().String()
+	}
 
 	dag := NewDag()
+	w.generated = nil
 	for i := range w.Steps {
 		task := &w.Steps[i]
-		// Propagate backend if not set
-		if task.Backend == "" {
-			task.SetBackend(w.Backend)
-		}
-		// Propagate logger if not set
-		if task.logger == nil {
-			task.logger = w.logger
-		}
-		// Propagate EnvMap if not set
-		if len(task.EnvMap) == 0 && len(w.EnvMap) > 0 {
-			task.EnvMap = w.EnvMap
-		}
+		w.prepareTask(task)
 		if err := dag.AddTask(task); err != nil {
 			w.logger.Error("Failed to add task to DAG", zap.String("task", task.Name), zap.Error(err))
-			return &WorkflowError{
+			return nil, &WorkflowError{
 				StepName:     task.Name,
 				WorkflowName: w.Name,
 				Err:          err,
@@ -107,31 +354,157 @@ func (w *Workflow) Run() error {
 	}
 	if err := dag.Validate(); err != nil {
 		w.logger.Error("DAG validation failed", zap.Error(err))
-		return &WorkflowError{
+		return nil, &WorkflowError{
+			StepName:     "DAG",
+			WorkflowName: w.Name,
+			Err:          err,
+		}
+	}
+	if err := validateGenerators(w.Steps); err != nil {
+		w.logger.Error("DAG validation failed", zap.Error(err))
+		return nil, &WorkflowError{
 			StepName:     "DAG",
 			WorkflowName: w.Name,
 			Err:          err,
 		}
 	}
-	err := w.runParallelDAG(dag)
+	pruned, err := w.Selection.prune(dag, w.Steps)
+	if err != nil {
+		w.logger.Error("Task selection failed", zap.Error(err))
+		return nil, &WorkflowError{
+			StepName:     "selection",
+			WorkflowName: w.Name,
+			Err:          err,
+		}
+	}
+	if len(pruned) > 0 {
+		w.logger.Info("Pruned tasks", zap.String("workflow", w.Name), zap.Int("pruned", len(pruned)), zap.Int("selected", len(w.Steps)-len(pruned)))
+	}
+	var done map[string]TaskState
+	if w.resume != nil {
+		if done, err = w.resume.completedTasks(w); err != nil {
+			w.logger.Error("Cannot resume run", zap.String("run", w.RunID), zap.Error(err))
+			return nil, &WorkflowError{
+				StepName:     "resume",
+				WorkflowName: w.Name,
+				Err:          err,
+			}
+		}
+		w.logger.Info("Resuming run", zap.String("run", w.RunID), zap.Int("skipped", len(done)))
+	}
+	if !w.SkipPreflight {
+		if err := w.preflight(pruned); err != nil {
+			w.logger.Error("Preflight failed", zap.Error(err))
+			return nil, &WorkflowError{
+				StepName:     "preflight",
+				WorkflowName: w.Name,
+				Err:          err,
+			}
+		}
+	}
+	w.initState()
+	results, err := w.runParallelDAG(dag, done, pruned)
 	w.logger.Info("Completed workflow", zap.String("workflow", w.Name))
-	return err
+	return results, err
+}
+
+// prepareTask propagates the workflow's backend, logger, backend registry and
+// EnvMap to a task that does not set its own.
+func (w *Workflow) prepareTask(task *Task) {
+	// Propagate backend if not set
+	if task.Backend == "" {
+		task.SetBackend(w.Backend)
+	}
+	// Propagate logger if not set
+	if task.logger == nil {
+		task.logger = w.logger
+	}
+	// Propagate workflow backend registry
+	if task.backends == nil {
+		task.backends = w.backends
+	}
+	task.parent = w
+	// Propagate EnvMap if not set
+	if len(task.EnvMap) == 0 && len(w.EnvMap) > 0 {
+		task.EnvMap = w.EnvMap
+	}
 }
 
 // runParallelDAG executes the tasks in the DAG in parallel according to dependencies.
-// Returns the first error encountered, or nil if all tasks succeed.
-func (w *Workflow) runParallelDAG(dag *DAG) error {
+// Returns the per-task results and the first error encountered, or nil if all tasks succeed.
+// Tasks in pruned are not run; their dependents do not wait for them.
+func (w *Workflow) runParallelDAG(dag *DAG, done map[string]TaskState, pruned map[string]string) (map[string]*TaskResult, error) {
 	order, err := dag.GetTopologicalOrder()
 	if err != nil {
 		w.logger.Error("DAG topological sort failed", zap.Error(err))
-		return &WorkflowError{
+		return nil, &WorkflowError{
 			StepName:     "DAG",
 			WorkflowName: w.Name,
 			Err:          err,
 		}
 	}
+	if len(pruned) > 0 {
+		selected := order[:0]
+		for _, t := range order {
+			if _, ok := pruned[t.Name]; !ok {
+				selected = append(selected, t)
+			}
+		}
+		order = selected
+	}
 	scheduler := newDagScheduler(w, order)
-	return scheduler.run()
+	scheduler.dag = dag
+	scheduler.resume(done)
+	err = scheduler.run()
+	scheduler.runAlways()
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	if w.context().Err() != nil {
+		err = scheduler.interrupted(err)
+	}
+	for name := range pruned {
+		scheduler.results[name] = &TaskResult{Name: name, Status: TaskStatusPruned}
+	}
+	return scheduler.results, err
+}
+
+// initState starts the run state saved to StateDir, continuing the resumed run's state if any.
+func (w *Workflow) initState() {
+	if w.StateDir == "" {
+		w.state = nil
+		return
+	}
+	w.state = &RunState{
+		RunID:     w.RunID,
+		Workflow:  w.Name,
+		StartedAt: time.Now(),
+		Tasks:     make(map[string]TaskState),
+	}
+	if w.resume != nil {
+		w.state.StartedAt = w.resume.StartedAt
+		for name, st := range w.resume.Tasks {
+			w.state.Tasks[name] = st
+		}
+	}
+	w.writeState()
+}
+
+// saveTaskState records a finished task in the run state and saves it.
+// The scheduler calls it with its lock held, so saves are serialized.
+func (w *Workflow) saveTaskState(task *Task, res *TaskResult) {
+	if w.state == nil {
+		return
+	}
+	w.state.Tasks[task.Name] = newTaskState(task, res)
+	w.writeState()
+}
+
+// writeState saves the run state. Failing to save is logged but does not fail the run.
+func (w *Workflow) writeState() {
+	w.state.UpdatedAt = time.Now()
+	if err := w.state.Save(w.StateDir); err != nil {
+		w.logger.Warn("Failed to save run state", zap.String("run", w.RunID), zap.Error(err))
+	}
 }
 
 // Add hook registration methods
@@ -191,6 +564,12 @@ func (w *Workflow) AddTask(task Task) *Workflow {
 	return w
 }
 
+// SetSkipPreflight enables or disables skipping the preflight pass in Run.
+func (w *Workflow) SetSkipPreflight(skip bool) *Workflow {
+	w.SkipPreflight = skip
+	return w
+}
+
 // AddImage sets the container image for the workflow
 func (w *Workflow) AddImage(image string) *Workflow {
 	w.Image = image
@@ -1,6 +1,7 @@
 package iapetus_test
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"os/exec"
@@ -25,7 +26,7 @@ func TestWorkflowRun(t *testing.T) {
 			workflow: func() *iapetus.Workflow {
 				wf := iapetus.NewWorkflow("all-steps-pass", zap.NewNop())
 				wf.Backend = "bash"
-				iapetus.RegisterBackend("bash", &iapetus.BashBackend{})
+				_ = wf.RegisterBackend("bash", &iapetus.BashBackend{})
 				wf.AddTask(iapetus.Task{
 					Name:    "step1",
 					Command: "echo",
@@ -270,7 +271,7 @@ func (m *mockBackend) GetStatus() string                     { return "mock" }
 
 func TestWorkflow_BackendPropagation(t *testing.T) {
 	called := false
-	iapetus.RegisterBackend("mock", &mockBackend{called: &called})
+	registerTestBackend(t, "mock", &mockBackend{called: &called})
 	wf := iapetus.NewWorkflow("test-backend", zap.NewNop())
 	wf.Backend = "mock"
 	task := iapetus.Task{
@@ -317,7 +318,7 @@ func TestWorkflow_EnvMapPropagation(t *testing.T) {
 
 func TestWorkflow_PerTaskBackendOverride(t *testing.T) {
 	called := false
-	iapetus.RegisterBackend("mock2", &mockBackend{called: &called})
+	registerTestBackend(t, "mock2", &mockBackend{called: &called})
 	wf := iapetus.NewWorkflow("test-per-task-backend", zap.NewNop())
 	wf.Backend = "bash"
 	task := iapetus.Task{
@@ -349,3 +350,73 @@ func TestWorkflow_ErrorOnMissingBackend(t *testing.T) {
 		t.Errorf("expected error for missing backend, got %v", err)
 	}
 }
+
+// countingBackend counts calls and runs the task's assertions against a fixed output.
+type countingBackend struct {
+	calls map[string]int
+}
+
+func (c *countingBackend) RunTask(task *iapetus.Task) error {
+	c.calls[task.Name]++
+	task.Actual.Output = "built"
+	return iapetus.RunAssertions(task)
+}
+func (c *countingBackend) ValidateTask(task *iapetus.Task) error { return nil }
+func (c *countingBackend) GetName() string                       { return "counting" }
+func (c *countingBackend) GetStatus() string                     { return "available" }
+
+func TestWorkflow_ResultWithWarnings(t *testing.T) {
+	backend := &countingBackend{calls: map[string]int{}}
+	registerTestBackend(t, "counting", backend)
+	wf := iapetus.NewWorkflow("test-result", zap.NewNop())
+	wf.Backend = "counting"
+	build := iapetus.NewTask("build", 0, zap.NewNop()).
+		AddCommand("make").
+		SetRetries(3).
+		AssertOutputContains("built").
+		AddWarning(iapetus.AssertOutputContains("cached"))
+	wf.AddTask(*build)
+	test := iapetus.NewTask("test", 0, zap.NewNop()).AddCommand("make").AssertOutputContains("built")
+	test.Depends = []string{"build"}
+	wf.AddTask(*test)
+
+	if err := wf.Run(); err != nil {
+		t.Fatalf("expected warnings not to fail the workflow, got %v", err)
+	}
+	if backend.calls["build"] != 1 {
+		t.Errorf("expected warnings not to trigger retries, got %d calls", backend.calls["build"])
+	}
+	res := wf.Result()
+	if res == nil || res.Count(iapetus.TaskStatusSucceeded) != 2 || res.Err != nil {
+		t.Fatalf("expected 2 succeeded tasks, got %+v", res)
+	}
+	warnings := res.Warnings()
+	if len(warnings) != 1 || len(warnings["build"]) != 1 {
+		t.Errorf("expected one warning on build, got %v", warnings)
+	}
+}
+
+func TestWorkflow_ResultStatuses(t *testing.T) {
+	backend := &countingBackend{calls: map[string]int{}}
+	registerTestBackend(t, "counting", backend)
+	wf := iapetus.NewWorkflow("test-result-fail", zap.NewNop())
+	wf.Backend = "counting"
+	wf.AddTask(*iapetus.NewTask("build", 0, zap.NewNop()).AddCommand("make").AssertOutputContains("nope"))
+	deploy := iapetus.NewTask("deploy", 0, zap.NewNop()).AddCommand("make")
+	deploy.Depends = []string{"build"}
+	wf.AddTask(*deploy)
+
+	if err := wf.Run(); err == nil {
+		t.Fatalf("expected workflow to fail")
+	}
+	res := wf.Result()
+	if got := res.Task("build"); got == nil || got.Status != iapetus.TaskStatusFailed || !errors.As(got.Err, new(iapetus.AssertionErrors)) {
+		t.Errorf("expected build to fail, got %+v", got)
+	}
+	if got := res.Task("deploy"); got == nil || got.Status != iapetus.TaskStatusPending {
+		t.Errorf("expected deploy to stay pending, got %+v", got)
+	}
+	if res.Err == nil {
+		t.Errorf("expected run error in result")
+	}
+}
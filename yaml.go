@@ -2,34 +2,123 @@
 //
 // Example YAML schema:
 //
-// name: my-workflow
-// backend: bash
-// env_map:
+//	name: my-workflow
+//	backend: bash
+//	skip_preflight: false
+//	max_parallel: 4
+//	critical_path: true
+//	timeout: 30m               # bounds the whole run
+//	grace_period: 20s          # time running steps get to stop when cancelled
+//	env_map:
+//	  FOO: bar
+//	steps:
+//	  - name: step1
+//	    command: echo
+//	    args: ["hello"]
+//	    timeout: 10s
+//	    backend: bash
+//	    env_map:
+//	      BAR: baz
+//	    raw_asserts:
+//	      - exit_code: 0
+//	      - output_contains: hello
+//	      - output_equals: "hello\n"
+//	      - output_matches_regexp: '^hello.*$'
+//	      - output_json_equals: '{"foo": 1}'
+//	        skip_json_nodes: ["foo.bar", "items.*.metadata.uid", "**.resourceVersion"]
+//	        ignore_array_order: true
+//	        float_tolerance: 0.001
+//	  - name: step2
+//	    command: echo
+//	    args: ["world"]
+//	    depends: [step1]
+//	    priority: 10
+//	    tags: [smoke]
+//	    cache: {inputs: ["go.sum", "src"]}
+//	    raw_asserts:
+//	      - output_equals: "world\n"
+//	  - name: health
+//	    http:
+//	      method: GET
+//	      url: https://localhost:8443/healthz
+//	      headers: {Accept: application/json}
+//	      insecure_skip_verify: true
+//	    raw_asserts:
+//	      - status_code: 200
+//	      - header_equals: {Content-Type: application/json}
+//	      - json_path: {path: "status", equals: "ok"}
+//	      - json_path: {path: "checks[*].name", contains: "db", length: 2}
+//	      - json_path: {path: "uptime", greater_than: 0}
+//	      - json_path: {path: "version", matches: '^v\d+'}
+//	      - output_json_schema: {type: object, required: [status]}
+//	      - output_json_schema_file: schemas/health.json
+//	  - name: manifests
+//	    command: helm
+//	    args: ["template", "./chart"]
+//	    raw_asserts:
+//	      - output_yaml_equals: |
+//	          kind: Service
+//	          ---
+//	          kind: Deployment
+//	        skip_json_nodes: ["**.labels"]
+//	      - json_path: {path: "[1].spec.replicas", format: yaml, equals: 3}
+//	      - output_toml_equals: 'title = "x"'
+//	      - output_csv_equals: "name,age\nalice,30"
+//	      - output_golden: testdata/manifests.golden
+//	        golden_normalizers: [timestamps, uuids, ansi]
+//	  - name: lint
+//	    command: ./lint.sh
+//	    raw_asserts:
+//	      - exit_code_in: [0, 2]
+//	      - exit_code_range: {min: 0, max: 2}
+//	      - output_not_contains: panic
+//	      - not: {output_matches_regexp: '(?i)error'}
+//	      - any_of: [{output_contains: "no issues"}, {all_of: [{output_contains: warning}, {exit_code: 2}]}]
+//	  - name: build
+//	    command: ./build.sh
+//	    working_dir: ./app
+//	    raw_asserts:
+//	      - duration_under: 2m
+//	      - file: {path: dist/app.tar.gz, exists: true, sha256: "9f86d0..."}
+//	      - file: {path: dist/manifest.json, json_equals: '{"name": "app"}'}
+//	      - env_equals: {VERSION: "1.2.3"}   # exported with: echo VERSION=1.2.3 >> "$IAPETUS_ENV"
+//	      - env_set: [BUILD_ID]
+//	  - name: deploy-db
+//	    depends: [build]
+//	    workflow:
+//	      path: workflows/db.yaml   # relative to this file
+//	      params: {DB_VERSION: "15"}
+//	      outputs: {DB_URL: migrate.DB_URL}
+//	    raw_asserts:
+//	      - env_set: [DB_URL]
+//	  - name: discover
+//	    command: ./list-suites.sh   # prints a JSON list, e.g. ["api", "web"]
+//	    generate:
+//	      template:
+//	        name: "test-{{item}}"
+//	        command: go
+//	        args: ["test", "./{{item}}/..."]
+//	      fan_in: [report]
+//	  - name: report
+//	    command: ./report.sh
+//	    depends: [discover]
+//	  - name: teardown
+//	    command: ./cleanup.sh
+//	    depends: [deploy-db]
+//	    always_run: true          # also runs if the workflow fails or is cancelled
+//	  - name: pods
+//	    command: kubectl
+//	    args: ["get", "pods", "-o", "json"]
+//	    raw_asserts:
+//	      - expr: 'exit_code == 0 && json.items.all(p, p.status.phase == "Running")'
+//	      - expr: 'size(json.items) >= 2 && duration < duration("30s")'
+//	      - custom: pods_running   # registered in Go with RegisterAssertion
+//	        args: {min: 2}
+//	      - output_not_contains: "deprecated"
+//	        severity: warning       # recorded in the run result, does not fail the step
 //
-//	FOO: bar
-//
-// steps:
-//   - name: step1
-//     command: echo
-//     args: ["hello"]
-//     timeout: 10s
-//     backend: bash
-//     env_map:
-//     BAR: baz
-//     raw_asserts:
-//   - exit_code: 0
-//   - output_contains: hello
-//   - output_equals: "hello\n"
-//   - output_json_equals: '{"foo": 1}'
-//   - output_matches_regexp: '^hello.*$'
-//   - output_json_equals: '{"foo": 1}'
-//     skip_json_nodes: ["foo.bar"]
-//   - name: step2
-//     command: echo
-//     args: ["world"]
-//     depends: [step1]
-//     raw_asserts:
-//   - output_equals: "world\n"
+// Steps using `backend: func` call a Go handler registered with RegisterTaskFunc;
+// `command` names the handler.
 //
 // Note: Only fields that can be represented in YAML (strings, ints, slices, maps, etc.)
 // are supported. Assertions (functions) must be added programmatically after loading using raw_asserts.
@@ -42,8 +131,12 @@
 package iapetus
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -55,12 +148,403 @@ var DefaultRetryDelay time.Duration = 1 * time.Second
 // assertionYAML is a helper struct for parsing assertions from YAML
 // Supports all built-in assertion types.
 type assertionYAML struct {
-	ExitCode            *int     `yaml:"exit_code,omitempty"`
-	OutputEquals        *string  `yaml:"output_equals,omitempty"`
-	OutputContains      *string  `yaml:"output_contains,omitempty"`
-	OutputJsonEquals    *string  `yaml:"output_json_equals,omitempty"`
-	OutputMatchesRegexp *string  `yaml:"output_matches_regexp,omitempty"`
-	SkipJsonNodes       []string `yaml:"skip_json_nodes,omitempty"`
+	ExitCode             *int               `yaml:"exit_code,omitempty"`
+	OutputEquals         *string            `yaml:"output_equals,omitempty"`
+	OutputContains       *string            `yaml:"output_contains,omitempty"`
+	OutputJsonEquals     *string            `yaml:"output_json_equals,omitempty"`
+	OutputMatchesRegexp  *string            `yaml:"output_matches_regexp,omitempty"`
+	OutputYamlEquals     *string            `yaml:"output_yaml_equals,omitempty"`
+	OutputTomlEquals     *string            `yaml:"output_toml_equals,omitempty"`
+	OutputCsvEquals      *string            `yaml:"output_csv_equals,omitempty"`
+	OutputGolden         *string            `yaml:"output_golden,omitempty"`
+	GoldenNormalizers    []string           `yaml:"golden_normalizers,omitempty"`
+	OutputNotContains    *string            `yaml:"output_not_contains,omitempty"`
+	ExitCodeIn           []int              `yaml:"exit_code_in,omitempty"`
+	ExitCodeRange        *exitCodeRangeYAML `yaml:"exit_code_range,omitempty"`
+	Not                  *assertionYAML     `yaml:"not,omitempty"`
+	AnyOf                []assertionYAML    `yaml:"any_of,omitempty"`
+	AllOf                []assertionYAML    `yaml:"all_of,omitempty"`
+	DurationUnder        *string            `yaml:"duration_under,omitempty"`
+	File                 *fileYAML          `yaml:"file,omitempty"`
+	EnvEquals            map[string]string  `yaml:"env_equals,omitempty"`
+	EnvSet               []string           `yaml:"env_set,omitempty"`
+	Expr                 *string            `yaml:"expr,omitempty"`
+	Custom               *string            `yaml:"custom,omitempty"`
+	Args                 yaml.Node          `yaml:"args,omitempty"`
+	Severity             string             `yaml:"severity,omitempty"`
+	SkipJsonNodes        []string           `yaml:"skip_json_nodes,omitempty"`
+	IgnoreArrayOrder     bool               `yaml:"ignore_array_order,omitempty"`
+	FloatTolerance       float64            `yaml:"float_tolerance,omitempty"`
+	StatusCode           *int               `yaml:"status_code,omitempty"`
+	HeaderEquals         map[string]string  `yaml:"header_equals,omitempty"`
+	JSONPath             *jsonPathYAML      `yaml:"json_path,omitempty"`
+	OutputJSONSchema     yaml.Node          `yaml:"output_json_schema,omitempty"`
+	OutputJSONSchemaFile *string            `yaml:"output_json_schema_file,omitempty"`
+}
+
+// exitCodeRangeYAML is an inclusive exit code range, e.g. {min: 0, max: 2}.
+type exitCodeRangeYAML struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// fileYAML is a file assertion, e.g. {path: dist/app.tar.gz, exists: true, sha256: "..."}.
+// Relative paths are resolved against the task's working_dir when the task runs.
+type fileYAML struct {
+	Path       string  `yaml:"path"`
+	Exists     *bool   `yaml:"exists"`
+	Contains   *string `yaml:"contains"`
+	SHA256     *string `yaml:"sha256"`
+	JSONEquals *string `yaml:"json_equals"`
+}
+
+// toAssertions converts a file entry into assertion functions.
+func (f *fileYAML) toAssertions(opts JSONCompareOptions) ([]func(*Task) error, error) {
+	if f.Path == "" {
+		return nil, fmt.Errorf("file assertion requires a path")
+	}
+	var asserts []func(*Task) error
+	if f.Exists != nil {
+		if *f.Exists {
+			asserts = append(asserts, AssertFileExists(f.Path))
+		} else {
+			asserts = append(asserts, Not(AssertFileExists(f.Path)))
+		}
+	}
+	if f.Contains != nil {
+		asserts = append(asserts, AssertFileContains(f.Path, *f.Contains))
+	}
+	if f.SHA256 != nil {
+		asserts = append(asserts, AssertFileChecksum(f.Path, *f.SHA256))
+	}
+	if f.JSONEquals != nil {
+		var v interface{}
+		if err := json.Unmarshal([]byte(*f.JSONEquals), &v); err != nil {
+			return nil, fmt.Errorf("file json_equals: invalid JSON: %w", err)
+		}
+		asserts = append(asserts, AssertFileJSONEquals(f.Path, *f.JSONEquals, opts))
+	}
+	if len(asserts) == 0 {
+		return nil, fmt.Errorf("file assertion for %q requires exists, contains, sha256 or json_equals", f.Path)
+	}
+	return asserts, nil
+}
+
+// jsonPathYAML is a JSON path assertion, e.g. {path: "items[0].name", equals: "foo"}.
+// At least one of equals, contains, greater_than, length or matches must be set.
+// Format selects how the output is parsed (json by default; yaml, toml or csv).
+type jsonPathYAML struct {
+	Path        string    `yaml:"path"`
+	Format      string    `yaml:"format"`
+	Equals      yaml.Node `yaml:"equals"`
+	Contains    yaml.Node `yaml:"contains"`
+	GreaterThan *float64  `yaml:"greater_than"`
+	Length      *int      `yaml:"length"`
+	Matches     *string   `yaml:"matches"`
+}
+
+// toAssertions converts a json_path entry into assertion functions.
+func (j *jsonPathYAML) toAssertions() ([]func(*Task) error, error) {
+	if j.Path == "" {
+		return nil, fmt.Errorf("json_path assertion requires a path")
+	}
+	if _, err := parseJSONPath(j.Path); err != nil {
+		return nil, err
+	}
+	format := j.Format
+	if format == "" {
+		format = FormatJSON
+	}
+	if !isStructuredFormat(format) {
+		return nil, fmt.Errorf("json_path: unsupported output format %q", format)
+	}
+	var asserts []func(*Task) error
+	if !isZeroNode(j.Equals) {
+		var v interface{}
+		if err := j.Equals.Decode(&v); err != nil {
+			return nil, fmt.Errorf("json_path equals: %w", err)
+		}
+		asserts = append(asserts, AssertOutputPath(format, j.Path, PathEquals(v)))
+	}
+	if !isZeroNode(j.Contains) {
+		var v interface{}
+		if err := j.Contains.Decode(&v); err != nil {
+			return nil, fmt.Errorf("json_path contains: %w", err)
+		}
+		asserts = append(asserts, AssertOutputPath(format, j.Path, PathContains(v)))
+	}
+	if j.GreaterThan != nil {
+		asserts = append(asserts, AssertOutputPath(format, j.Path, PathGreaterThan(*j.GreaterThan)))
+	}
+	if j.Length != nil {
+		asserts = append(asserts, AssertOutputPath(format, j.Path, PathLength(*j.Length)))
+	}
+	if j.Matches != nil {
+		if _, err := regexp.Compile(*j.Matches); err != nil {
+			return nil, fmt.Errorf("json_path matches: invalid regexp %q: %w", *j.Matches, err)
+		}
+		asserts = append(asserts, AssertOutputPath(format, j.Path, PathMatches(*j.Matches)))
+	}
+	if len(asserts) == 0 {
+		return nil, fmt.Errorf("json_path assertion for %q requires equals, contains, greater_than, length or matches", j.Path)
+	}
+	return asserts, nil
+}
+
+// isZeroNode reports whether a yaml.Node field was absent from the document.
+func isZeroNode(n yaml.Node) bool {
+	return n.Kind == 0
+}
+
+// customAssertionArgs decodes the args mapping of a custom assertion into JSON-compatible values.
+func customAssertionArgs(node yaml.Node) (map[string]interface{}, error) {
+	if isZeroNode(node) {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("custom assertion args must be a mapping")
+	}
+	var raw map[string]interface{}
+	if err := node.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("custom assertion args: %w", err)
+	}
+	v, err := toJSONCompatible(raw)
+	if err != nil {
+		return nil, fmt.Errorf("custom assertion args: %w", err)
+	}
+	args, _ := v.(map[string]interface{})
+	return args, nil
+}
+
+// jsonSchemaFromNode accepts a JSON Schema as a string or as an inline YAML mapping.
+func jsonSchemaFromNode(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// jsonCompareOptions returns the comparison options shared by the structured equality assertions.
+func (a assertionYAML) jsonCompareOptions() JSONCompareOptions {
+	return JSONCompareOptions{
+		SkipPaths:        a.SkipJsonNodes,
+		IgnoreArrayOrder: a.IgnoreArrayOrder,
+		FloatTolerance:   a.FloatTolerance,
+	}
+}
+
+// nestedAssertions converts the entries of an any_of/all_of list, combining the
+// assertions of each entry with AllOf.
+func nestedAssertions(key string, entries []assertionYAML, baseDir string) ([]func(*Task) error, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: requires at least one assertion", key)
+	}
+	out := make([]func(*Task) error, 0, len(entries))
+	for idx, entry := range entries {
+		inner, err := entry.toAssertions(baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", key, idx, err)
+		}
+		if len(inner) == 0 {
+			return nil, fmt.Errorf("%s[%d]: requires an assertion", key, idx)
+		}
+		out = append(out, AllOf(inner...))
+	}
+	return out, nil
+}
+
+// toAssertions converts a raw_asserts entry into assertion functions.
+// Relative file paths are resolved against baseDir (the YAML file's directory).
+func (a assertionYAML) toAssertions(baseDir string) ([]func(*Task) error, error) {
+	asserts, err := a.buildAssertions(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	switch a.Severity {
+	case "", SeverityError:
+		return asserts, nil
+	case SeverityWarning:
+		for i, assert := range asserts {
+			asserts[i] = Warn(assert)
+		}
+		return asserts, nil
+	default:
+		return nil, fmt.Errorf("invalid severity %q (expected %q or %q)", a.Severity, SeverityError, SeverityWarning)
+	}
+}
+
+// buildAssertions converts the assertion keys of a raw_asserts entry into assertion functions.
+func (a assertionYAML) buildAssertions(baseDir string) ([]func(*Task) error, error) {
+	var asserts []func(*Task) error
+	if a.ExitCode != nil {
+		asserts = append(asserts, AssertExitCode(*a.ExitCode))
+	}
+	if a.OutputEquals != nil {
+		asserts = append(asserts, AssertOutputEquals(*a.OutputEquals))
+	}
+	if a.OutputContains != nil {
+		asserts = append(asserts, AssertOutputContains(*a.OutputContains))
+	}
+	if a.OutputNotContains != nil {
+		asserts = append(asserts, AssertOutputNotContains(*a.OutputNotContains))
+	}
+	if len(a.ExitCodeIn) > 0 {
+		asserts = append(asserts, AssertExitCodeIn(a.ExitCodeIn...))
+	}
+	if a.ExitCodeRange != nil {
+		if a.ExitCodeRange.Min > a.ExitCodeRange.Max {
+			return nil, fmt.Errorf("exit_code_range: min %d is greater than max %d", a.ExitCodeRange.Min, a.ExitCodeRange.Max)
+		}
+		asserts = append(asserts, AssertExitCodeInRange(a.ExitCodeRange.Min, a.ExitCodeRange.Max))
+	}
+	if a.Not != nil {
+		inner, err := a.Not.toAssertions(baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("not: %w", err)
+		}
+		if len(inner) == 0 {
+			return nil, fmt.Errorf("not: requires an assertion")
+		}
+		asserts = append(asserts, Not(AllOf(inner...)))
+	}
+	if a.AnyOf != nil {
+		alternatives, err := nestedAssertions("any_of", a.AnyOf, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, AnyOf(alternatives...))
+	}
+	if a.AllOf != nil {
+		all, err := nestedAssertions("all_of", a.AllOf, baseDir)
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, AllOf(all...))
+	}
+	if a.OutputJsonEquals != nil {
+		asserts = append(asserts, AssertOutputJsonEqualsWithOptions(*a.OutputJsonEquals, a.jsonCompareOptions()))
+	}
+	if a.OutputMatchesRegexp != nil {
+		asserts = append(asserts, AssertOutputMatchesRegexp(*a.OutputMatchesRegexp))
+	}
+	structured := []struct {
+		format   string
+		expected *string
+	}{
+		{FormatYAML, a.OutputYamlEquals},
+		{FormatTOML, a.OutputTomlEquals},
+		{FormatCSV, a.OutputCsvEquals},
+	}
+	for _, s := range structured {
+		if s.expected == nil {
+			continue
+		}
+		if _, err := ParseStructuredOutput(s.format, *s.expected); err != nil {
+			return nil, fmt.Errorf("output_%s_equals: %w", s.format, err)
+		}
+		asserts = append(asserts, AssertOutputStructuredEquals(s.format, *s.expected, a.jsonCompareOptions()))
+	}
+	if a.OutputGolden != nil {
+		var normalizers []Normalizer
+		for _, name := range a.GoldenNormalizers {
+			n := GetNormalizer(name)
+			if n == nil {
+				return nil, fmt.Errorf("golden_normalizers: unknown normalizer %q (available: %s)", name, strings.Join(listNormalizers(), ", "))
+			}
+			normalizers = append(normalizers, n)
+		}
+		asserts = append(asserts, AssertOutputMatchesGolden(resolvePath(baseDir, *a.OutputGolden), normalizers...))
+	}
+	if a.DurationUnder != nil {
+		d, err := time.ParseDuration(*a.DurationUnder)
+		if err != nil {
+			return nil, fmt.Errorf("duration_under: %w", err)
+		}
+		asserts = append(asserts, AssertDurationUnder(d))
+	}
+	if a.File != nil {
+		fileAsserts, err := a.File.toAssertions(a.jsonCompareOptions())
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, fileAsserts...)
+	}
+	for name, value := range a.EnvEquals {
+		asserts = append(asserts, AssertEnvEquals(name, value))
+	}
+	for _, name := range a.EnvSet {
+		asserts = append(asserts, AssertEnvSet(name))
+	}
+	if a.Expr != nil {
+		assert, err := AssertExpr(*a.Expr)
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, assert)
+	}
+	if a.Custom != nil {
+		args, err := customAssertionArgs(a.Args)
+		if err != nil {
+			return nil, err
+		}
+		assert, err := NewAssertion(*a.Custom, args)
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, assert)
+	} else if !isZeroNode(a.Args) {
+		return nil, fmt.Errorf("args is only valid with custom")
+	}
+	if a.StatusCode != nil {
+		asserts = append(asserts, AssertStatusCode(*a.StatusCode))
+	}
+	for name, value := range a.HeaderEquals {
+		asserts = append(asserts, AssertHeaderEquals(name, value))
+	}
+	if a.JSONPath != nil {
+		jsonAsserts, err := a.JSONPath.toAssertions()
+		if err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, jsonAsserts...)
+	}
+	if !isZeroNode(a.OutputJSONSchema) {
+		schema, err := jsonSchemaFromNode(&a.OutputJSONSchema)
+		if err != nil {
+			return nil, fmt.Errorf("output_json_schema: %w", err)
+		}
+		if _, err := compileJSONSchema(schema); err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, AssertOutputJSONSchema(schema))
+	}
+	if a.OutputJSONSchemaFile != nil {
+		data, err := os.ReadFile(resolvePath(baseDir, *a.OutputJSONSchemaFile))
+		if err != nil {
+			return nil, fmt.Errorf("output_json_schema_file: %w", err)
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("output_json_schema_file: %w", err)
+		}
+		schema, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("output_json_schema_file: %w", err)
+		}
+		if _, err := compileJSONSchema(string(schema)); err != nil {
+			return nil, err
+		}
+		asserts = append(asserts, AssertOutputJSONSchema(string(schema)))
+	}
+	return asserts, nil
 }
 
 type taskYAML struct {
@@ -71,17 +555,43 @@ type taskYAML struct {
 	Retries    int               `yaml:"retries,omitempty"`
 	RetryDelay string            `yaml:"retry_delay,omitempty"` // Delay between retries (e.g. "2s"). Defaults to 1s if not set.
 	Depends    []string          `yaml:"depends,omitempty"`
+	Priority   int               `yaml:"priority,omitempty"`
+	AlwaysRun  bool              `yaml:"always_run,omitempty"`
+	Tags       []string          `yaml:"tags,omitempty"`
+	Cache      *CacheOptions     `yaml:"cache,omitempty"`
 	EnvMap     map[string]string `yaml:"env_map,omitempty"`
 	Image      string            `yaml:"image,omitempty"`
+	WorkingDir string            `yaml:"working_dir,omitempty"`
 	Backend    string            `yaml:"backend,omitempty"`
+	HTTP       *HTTPRequest      `yaml:"http,omitempty"`
+	Workflow   *SubWorkflow      `yaml:"workflow,omitempty"`
+	Generate   *generatorYAML    `yaml:"generate,omitempty"`
 	RawAsserts []assertionYAML   `yaml:"raw_asserts,omitempty"`
 }
 
+type generatorYAML struct {
+	Template taskYAML `yaml:"template"`
+	FanIn    []string `yaml:"fan_in,omitempty"`
+}
+
 type workflowYAML struct {
-	Name    string            `yaml:"name"`
-	Backend string            `yaml:"backend,omitempty"`
-	EnvMap  map[string]string `yaml:"env_map,omitempty"`
-	Steps   []taskYAML        `yaml:"steps"`
+	Name          string            `yaml:"name"`
+	Backend       string            `yaml:"backend,omitempty"`
+	EnvMap        map[string]string `yaml:"env_map,omitempty"`
+	SkipPreflight bool              `yaml:"skip_preflight,omitempty"`
+	MaxParallel   int               `yaml:"max_parallel,omitempty"`
+	CriticalPath  bool              `yaml:"critical_path,omitempty"`
+	Timeout       string            `yaml:"timeout,omitempty"`
+	GracePeriod   string            `yaml:"grace_period,omitempty"`
+	Steps         []taskYAML        `yaml:"steps"`
+}
+
+// resolvePath resolves p against baseDir unless it is absolute.
+func resolvePath(baseDir, p string) string {
+	if filepath.IsAbs(p) || baseDir == "" {
+		return p
+	}
+	return filepath.Join(baseDir, p)
 }
 
 // LoadWorkflowFromYAML loads a Workflow from a YAML file.
@@ -110,53 +620,99 @@ func LoadWorkflowFromYAML(path string) (*Workflow, error) {
 	if wfY.EnvMap != nil {
 		wf.EnvMap = wfY.EnvMap
 	}
-	for _, t := range wfY.Steps {
-		task := Task{
-			Name:    t.Name,
-			Command: t.Command,
-			Args:    t.Args,
-			Retries: t.Retries,
-			Depends: t.Depends,
-			EnvMap:  t.EnvMap,
-			Image:   t.Image,
-		}
-		if t.Backend != "" {
-			task.Backend = t.Backend
-		}
-		if t.Timeout != "" {
-			dur, err := time.ParseDuration(t.Timeout)
-			if err != nil {
-				return nil, fmt.Errorf("invalid timeout for task %s: %w", t.Name, err)
-			}
-			task.Timeout = dur
+	wf.SkipPreflight = wfY.SkipPreflight
+	if wfY.MaxParallel < 0 {
+		return nil, fmt.Errorf("invalid max_parallel %d: must not be negative", wfY.MaxParallel)
+	}
+	wf.MaxParallel = wfY.MaxParallel
+	wf.CriticalPath = wfY.CriticalPath
+	if wfY.Timeout != "" {
+		dur, err := time.ParseDuration(wfY.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workflow timeout: %w", err)
 		}
-		if t.RetryDelay != "" {
-			dur, err := time.ParseDuration(t.RetryDelay)
-			if err != nil {
-				return nil, fmt.Errorf("invalid retry_delay for task %s: %w", t.Name, err)
-			}
-			task.RetryDelay = dur
-		} else {
-			task.RetryDelay = DefaultRetryDelay
+		if dur < 0 {
+			return nil, fmt.Errorf("invalid workflow timeout %v: must not be negative", dur)
 		}
-		for _, a := range t.RawAsserts {
-			if a.ExitCode != nil {
-				task.Asserts = append(task.Asserts, AssertExitCode(*a.ExitCode))
-			}
-			if a.OutputEquals != nil {
-				task.Asserts = append(task.Asserts, AssertOutputEquals(*a.OutputEquals))
-			}
-			if a.OutputContains != nil {
-				task.Asserts = append(task.Asserts, AssertOutputContains(*a.OutputContains))
-			}
-			if a.OutputJsonEquals != nil {
-				task.Asserts = append(task.Asserts, AssertOutputJsonEquals(*a.OutputJsonEquals, a.SkipJsonNodes...))
-			}
-			if a.OutputMatchesRegexp != nil {
-				task.Asserts = append(task.Asserts, AssertOutputMatchesRegexp(*a.OutputMatchesRegexp))
-			}
+		wf.Timeout = dur
+	}
+	if wfY.GracePeriod != "" {
+		dur, err := time.ParseDuration(wfY.GracePeriod)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grace_period: %w", err)
+		}
+		if dur < 0 {
+			return nil, fmt.Errorf("invalid grace_period %v: must not be negative", dur)
+		}
+		wf.GracePeriod = dur
+	}
+	for _, t := range wfY.Steps {
+		task, err := t.toTask(filepath.Dir(path))
+		if err != nil {
+			return nil, err
 		}
 		wf.AddTask(task)
 	}
 	return wf, nil
 }
+
+// toTask converts a YAML step into a Task, resolving paths against baseDir.
+func (t taskYAML) toTask(baseDir string) (Task, error) {
+	task := Task{
+		Name:       t.Name,
+		Command:    t.Command,
+		Args:       t.Args,
+		Retries:    t.Retries,
+		Depends:    t.Depends,
+		Priority:   t.Priority,
+		AlwaysRun:  t.AlwaysRun,
+		Tags:       t.Tags,
+		Cache:      t.Cache,
+		EnvMap:     t.EnvMap,
+		Image:      t.Image,
+		HTTP:       t.HTTP,
+		Workflow:   t.Workflow,
+		WorkingDir: t.WorkingDir,
+	}
+	if t.Workflow != nil && t.Workflow.Path != "" {
+		t.Workflow.Path = resolvePath(baseDir, t.Workflow.Path)
+	}
+	if t.Backend != "" {
+		task.Backend = t.Backend
+	} else if t.HTTP != nil {
+		task.Backend = "http"
+	} else if t.Workflow != nil {
+		task.Backend = "workflow"
+	}
+	if t.Timeout != "" {
+		dur, err := time.ParseDuration(t.Timeout)
+		if err != nil {
+			return Task{}, fmt.Errorf("invalid timeout for task %s: %w", t.Name, err)
+		}
+		task.Timeout = dur
+	}
+	if t.RetryDelay != "" {
+		dur, err := time.ParseDuration(t.RetryDelay)
+		if err != nil {
+			return Task{}, fmt.Errorf("invalid retry_delay for task %s: %w", t.Name, err)
+		}
+		task.RetryDelay = dur
+	} else {
+		task.RetryDelay = DefaultRetryDelay
+	}
+	for _, a := range t.RawAsserts {
+		asserts, err := a.toAssertions(baseDir)
+		if err != nil {
+			return Task{}, fmt.Errorf("invalid assertion for task %s: %w", t.Name, err)
+		}
+		task.Asserts = append(task.Asserts, asserts...)
+	}
+	if t.Generate != nil {
+		tmpl, err := t.Generate.Template.toTask(baseDir)
+		if err != nil {
+			return Task{}, fmt.Errorf("invalid generate template for task %s: %w", t.Name, err)
+		}
+		task.Generate = &Generator{Template: &tmpl, FanIn: t.Generate.FanIn}
+	}
+	return task, nil
+}
@@ -1,8 +1,13 @@
 package iapetus
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadWorkflowFromYAML_Success(t *testing.T) {
@@ -97,3 +102,445 @@ func TestLoadWorkflowFromYAML_InvalidYAML(t *testing.T) {
 		t.Error("expected error for invalid yaml, got nil")
 	}
 }
+
+func TestLoadWorkflowFromYAML_HTTPStep(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","checks":[{"name":"db"}]}`))
+	}))
+	defer srv.Close()
+	yamlContent := `
+name: http-wf
+steps:
+  - name: health
+    timeout: 2s
+    http:
+      url: ` + srv.URL + `/healthz
+      headers:
+        Accept: application/json
+    raw_asserts:
+      - status_code: 200
+      - header_equals: {Content-Type: application/json}
+      - json_path: {path: "status", equals: "ok"}
+      - json_path: {path: "checks[0].name", equals: "db"}
+`
+	f, err := os.CreateTemp("", "iapetus_yaml_http_*.yaml")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(yamlContent); err != nil {
+		t.Fatalf("failed to write yaml: %v", err)
+	}
+	f.Close()
+
+	wf, err := LoadWorkflowFromYAML(f.Name())
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	if wf.Steps[0].Backend != "http" {
+		t.Errorf("expected http backend, got %q", wf.Steps[0].Backend)
+	}
+	if len(wf.Steps[0].Asserts) != 4 {
+		t.Errorf("expected 4 assertions, got %d", len(wf.Steps[0].Asserts))
+	}
+	if err := wf.Run(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// writeTempYAML writes content to a temporary workflow file and returns its path.
+func writeTempYAML(t *testing.T, content string) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "iapetus_yaml_test_*.yaml")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("failed to write yaml: %v", err)
+	}
+	f.Close()
+	return f.Name()
+}
+
+func TestLoadWorkflowFromYAML_JSONPathAndSchema(t *testing.T) {
+	path := writeTempYAML(t, `
+name: json-wf
+steps:
+  - name: pods
+    command: echo
+    raw_asserts:
+      - json_path: {path: "items[*].status", contains: "Running", length: 2}
+      - json_path: {path: "count", greater_than: 1, equals: 2}
+      - json_path: {path: "version", matches: '^v\d+'}
+      - output_json_schema:
+          type: object
+          required: [items]
+      - output_json_schema_file: schema.json
+`)
+	schema := `{"type":"object","properties":{"count":{"type":"integer"}}}`
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "schema.json"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	if len(task.Asserts) != 7 {
+		t.Fatalf("expected 7 assertions, got %d", len(task.Asserts))
+	}
+	task.Actual.Output = `{"items":[{"status":"Running"},{"status":"Pending"}],"count":2,"version":"v3"}`
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+	task.Actual.Output = `{"items":[],"count":"2","version":"x"}`
+	if err := RunAssertions(task); err == nil {
+		t.Errorf("expected assertions to fail")
+	}
+}
+
+func TestLoadWorkflowFromYAML_StructuredAssertions(t *testing.T) {
+	path := writeTempYAML(t, `
+name: structured-wf
+steps:
+  - name: manifests
+    command: echo
+    raw_asserts:
+      - output_yaml_equals: |
+          kind: Service
+          ---
+          kind: Deployment
+          spec: {replicas: 3}
+        skip_json_nodes: ["**.labels"]
+      - json_path: {path: "[1].spec.replicas", format: yaml, equals: 3}
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	if len(task.Asserts) != 2 {
+		t.Fatalf("expected 2 assertions, got %d", len(task.Asserts))
+	}
+	task.Actual.Output = "kind: Service\nlabels: {app: web}\n---\nkind: Deployment\nspec:\n  replicas: 3\n"
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+	task.Actual.Output = "kind: Service\n---\nkind: Deployment\nspec:\n  replicas: 1\n"
+	if err := RunAssertions(task); err == nil {
+		t.Errorf("expected assertions to fail")
+	}
+
+	path = writeTempYAML(t, `
+name: toml-csv-wf
+steps:
+  - name: config
+    command: echo
+    raw_asserts:
+      - output_toml_equals: 'port = 8080'
+      - output_csv_equals: "port\n8080"
+`)
+	wf, err = LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	if len(wf.Steps[0].Asserts) != 2 {
+		t.Fatalf("expected 2 assertions, got %d", len(wf.Steps[0].Asserts))
+	}
+}
+
+func TestLoadWorkflowFromYAML_GoldenAssertion(t *testing.T) {
+	path := writeTempYAML(t, `
+name: golden-wf
+steps:
+  - name: list
+    command: echo
+    raw_asserts:
+      - output_golden: testdata/list.golden
+        golden_normalizers: [timestamps, ansi]
+`)
+	golden := filepath.Join(filepath.Dir(path), "testdata", "list.golden")
+	if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+		t.Fatalf("failed to create testdata: %v", err)
+	}
+	if err := os.WriteFile(golden, []byte("started <TIMESTAMP>\n"), 0o644); err != nil {
+		t.Fatalf("failed to write golden file: %v", err)
+	}
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	task.Actual.Output = "\x1b[1mstarted\x1b[0m 2024-01-02 03:04:05"
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected golden assertion to pass, got %v", err)
+	}
+}
+
+func TestLoadWorkflowFromYAML_CompositeAssertions(t *testing.T) {
+	path := writeTempYAML(t, `
+name: composite-wf
+steps:
+  - name: lint
+    command: echo
+    raw_asserts:
+      - exit_code_in: [0, 2]
+      - exit_code_range: {min: 0, max: 2}
+      - output_not_contains: panic
+      - not: {output_matches_regexp: '(?i)error'}
+      - any_of:
+          - output_contains: "no issues"
+          - all_of:
+              - output_contains: warning
+              - exit_code: 2
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	if len(task.Asserts) != 5 {
+		t.Fatalf("expected 5 assertions, got %d", len(task.Asserts))
+	}
+	task.Actual = Output{ExitCode: 2, Output: "warning: unused variable"}
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+	task.Actual = Output{ExitCode: 0, Output: "no issues"}
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+	task.Actual = Output{ExitCode: 1, Output: "Error: warning"}
+	if errs, ok := RunAssertions(task).(AssertionErrors); !ok || len(errs) != 3 {
+		t.Errorf("expected 3 failed assertions, got %v", errs)
+	}
+}
+
+func TestLoadWorkflowFromYAML_SideEffectAssertions(t *testing.T) {
+	path := writeTempYAML(t, `
+name: build-wf
+steps:
+  - name: build
+    command: echo
+    working_dir: /tmp/build
+    raw_asserts:
+      - duration_under: 1m
+      - file: {path: dist/app.txt, exists: true, contains: ok}
+      - file: {path: dist/stale.txt, exists: false}
+      - env_equals: {VERSION: "1.2.3"}
+      - env_set: [BUILD_ID]
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	if task.WorkingDir != "/tmp/build" {
+		t.Errorf("expected working_dir to be loaded, got %q", task.WorkingDir)
+	}
+	if len(task.Asserts) != 6 {
+		t.Fatalf("expected 6 assertions, got %d", len(task.Asserts))
+	}
+	task.WorkingDir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(task.WorkingDir, "dist"), 0o755); err != nil {
+		t.Fatalf("failed to create dist: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(task.WorkingDir, "dist", "app.txt"), []byte("ok"), 0o644); err != nil {
+		t.Fatalf("failed to write artifact: %v", err)
+	}
+	task.Actual = Output{Duration: time.Second, Env: map[string]string{"VERSION": "1.2.3", "BUILD_ID": "42"}}
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+
+	// exists: false must not pass when the file cannot be checked at all.
+	if err := os.RemoveAll(filepath.Join(task.WorkingDir, "dist")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(task.WorkingDir, "dist"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunAssertions(task); err == nil || !strings.Contains(err.Error(), "failed to check file") {
+		t.Errorf("expected exists: false to report the stat error, got %v", err)
+	}
+}
+
+func TestLoadWorkflowFromYAML_ExprAssertion(t *testing.T) {
+	path := writeTempYAML(t, `
+name: expr-wf
+steps:
+  - name: pods
+    command: echo
+    raw_asserts:
+      - expr: 'exit_code == 0 && json.items.all(p, p.status.phase == "Running")'
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	task.Actual.Output = `{"items":[{"status":{"phase":"Running"}}]}`
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected assertions to pass, got %v", err)
+	}
+	task.Actual.Output = `{"items":[{"status":{"phase":"Pending"}}]}`
+	if err := RunAssertions(task); err == nil {
+		t.Errorf("expected assertions to fail")
+	}
+}
+
+func TestLoadWorkflowFromYAML_SeverityWarning(t *testing.T) {
+	path := writeTempYAML(t, `
+name: severity-wf
+steps:
+  - name: build
+    command: echo
+    raw_asserts:
+      - exit_code: 0
+      - output_contains: cached
+        severity: warning
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	task := &wf.Steps[0]
+	task.Actual.Output = "built"
+	if err := RunAssertions(task); err != nil {
+		t.Errorf("expected warning not to fail, got %v", err)
+	}
+	if len(task.Actual.Warnings) != 1 || task.Actual.Warnings[0].Kind != KindOutputContains {
+		t.Errorf("expected one output_contains warning, got %v", task.Actual.Warnings)
+	}
+}
+
+func TestLoadWorkflowFromYAML_Scheduling(t *testing.T) {
+	path := writeTempYAML(t, `
+name: sched-wf
+max_parallel: 2
+critical_path: true
+timeout: 15m
+grace_period: 20s
+steps:
+  - name: slow
+    command: echo
+    priority: 10
+    cache: {inputs: [go.sum, src]}
+  - name: fast
+    command: echo
+    tags: [smoke, quick]
+    always_run: true
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	if wf.MaxParallel != 2 || !wf.CriticalPath {
+		t.Errorf("expected max_parallel 2 and critical_path, got %d and %v", wf.MaxParallel, wf.CriticalPath)
+	}
+	if wf.Timeout != 15*time.Minute {
+		t.Errorf("expected timeout 15m, got %v", wf.Timeout)
+	}
+	if wf.GracePeriod != 20*time.Second {
+		t.Errorf("expected grace_period 20s, got %v", wf.GracePeriod)
+	}
+	if wf.Steps[0].AlwaysRun || !wf.Steps[1].AlwaysRun {
+		t.Errorf("unexpected always_run %v and %v", wf.Steps[0].AlwaysRun, wf.Steps[1].AlwaysRun)
+	}
+	if wf.Steps[0].Priority != 10 || wf.Steps[1].Priority != 0 {
+		t.Errorf("unexpected priorities %d and %d", wf.Steps[0].Priority, wf.Steps[1].Priority)
+	}
+	if c := wf.Steps[0].Cache; c == nil || len(c.Inputs) != 2 || wf.Steps[1].Cache != nil {
+		t.Errorf("unexpected cache options %+v and %+v", wf.Steps[0].Cache, wf.Steps[1].Cache)
+	}
+	if !wf.Steps[1].HasAnyTag("quick") || wf.Steps[0].HasAnyTag("quick") {
+		t.Errorf("unexpected tags %v and %v", wf.Steps[0].Tags, wf.Steps[1].Tags)
+	}
+
+	path = writeTempYAML(t, "name: bad\nmax_parallel: -1\nsteps:\n  - name: s\n    command: echo\n")
+	if _, err := LoadWorkflowFromYAML(path); err == nil {
+		t.Errorf("expected error for negative max_parallel")
+	}
+	for _, timeout := range []string{"soon", "-1m"} {
+		path = writeTempYAML(t, "name: bad\ntimeout: "+timeout+"\nsteps:\n  - name: s\n    command: echo\n")
+		if _, err := LoadWorkflowFromYAML(path); err == nil {
+			t.Errorf("expected error for timeout %q", timeout)
+		}
+	}
+	path = writeTempYAML(t, "name: bad\ngrace_period: -5s\nsteps:\n  - name: s\n    command: echo\n")
+	if _, err := LoadWorkflowFromYAML(path); err == nil {
+		t.Errorf("expected error for negative grace_period")
+	}
+}
+
+func TestLoadWorkflowFromYAML_Generate(t *testing.T) {
+	path := writeTempYAML(t, `
+name: gen-wf
+steps:
+  - name: discover
+    command: echo
+    args: ['["api", "web"]']
+    generate:
+      template:
+        name: "test-{{item}}"
+        command: echo
+        args: ["{{item}}"]
+        timeout: 5s
+        raw_asserts:
+          - output_contains: "{{item}}"
+      fan_in: [report]
+  - name: report
+    command: echo
+    depends: [discover]
+`)
+	wf, err := LoadWorkflowFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflowFromYAML failed: %v", err)
+	}
+	g := wf.Steps[0].Generate
+	if g == nil || g.Template == nil {
+		t.Fatalf("expected a generator, got %+v", g)
+	}
+	if g.Template.Name != "test-{{item}}" || g.Template.Timeout != 5*time.Second || len(g.Template.Asserts) != 1 {
+		t.Errorf("unexpected template %+v", g.Template)
+	}
+	if len(g.FanIn) != 1 || g.FanIn[0] != "report" {
+		t.Errorf("unexpected fan_in %v", g.FanIn)
+	}
+
+	path = writeTempYAML(t, "name: bad\nsteps:\n  - name: s\n    command: echo\n    generate:\n      template: {name: x, timeout: soon}\n")
+	if _, err := LoadWorkflowFromYAML(path); err == nil {
+		t.Errorf("expected error for invalid template timeout")
+	}
+}
+
+func TestLoadWorkflowFromYAML_InvalidAssertions(t *testing.T) {
+	cases := map[string]string{
+		"no operator":    `- json_path: {path: "a"}`,
+		"bad regexp":     `- json_path: {path: "a", matches: "a["}`,
+		"bad schema":     `- output_json_schema: '{"type": 1}'`,
+		"missing schema": `- output_json_schema_file: does-not-exist.json`,
+		"bad format":     `- json_path: {path: "a", format: xml, equals: 1}`,
+		"bad toml":       `- output_toml_equals: "a = ["`,
+		"bad normalizer": `- {output_golden: out.golden, golden_normalizers: [nope]}`,
+		"empty not":      `- not: {}`,
+		"empty any_of":   `- any_of: []`,
+		"nested invalid": `- any_of: [{json_path: {path: "a"}}]`,
+		"bad range":      `- exit_code_range: {min: 3, max: 1}`,
+		"bad duration":   `- duration_under: soon`,
+		"file no op":     `- file: {path: a.txt}`,
+		"file bad json":  `- file: {path: a.json, json_equals: "{"}`,
+		"bad expr":       `- expr: "exit_code =="`,
+		"non-bool expr":  `- expr: "exit_code + 1"`,
+		"bad severity":   `- {output_contains: ok, severity: fatal}`,
+	}
+	for name, assertion := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := writeTempYAML(t, "name: bad\nsteps:\n  - name: s\n    command: echo\n    raw_asserts:\n      "+assertion+"\n")
+			if _, err := LoadWorkflowFromYAML(path); err == nil {
+				t.Errorf("expected load error")
+			}
+		})
+	}
+}